@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+var (
+	baselineFile    string
+	regressionsOnly bool
+)
+
+// resultKey identifies a query result across runs. Results without a role
+// keep the key they had before --roles existed, so older baselines still
+// match.
+func resultKey(result TestResult) string {
+	key := result.Path
+	if result.Target != "" {
+		key = result.Target + ":" + key
+	}
+	if result.Role != "" {
+		key = fmt.Sprintf("%s (%s)", key, result.Role)
+	}
+	return key
+}
+
+// loadBaseline reads the summary saved by a previous run, returning nil if
+// no baseline has been written yet
+func loadBaseline(path string) (*ValidationSummary, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var baseline ValidationSummary
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline %s: %w", path, err)
+	}
+	return &baseline, nil
+}
+
+// saveBaseline writes the summary of this run for the next comparison
+func saveBaseline(path string, summary ValidationSummary) error {
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// compareBaseline records queries that newly failed (regressions) and newly
+// passed (fixes) relative to the baseline. Queries absent from the
+// baseline and skipped queries are not compared.
+func compareBaseline(summary *ValidationSummary, baseline *ValidationSummary) {
+	previous := make(map[string]bool, len(baseline.Results))
+	for _, result := range baseline.Results {
+		if !result.Skipped {
+			previous[resultKey(result)] = result.Passed
+		}
+	}
+
+	for _, result := range summary.Results {
+		if result.Skipped {
+			continue
+		}
+		passed, ok := previous[resultKey(result)]
+		if !ok {
+			continue
+		}
+		switch {
+		case passed && !result.Passed:
+			summary.Regressions = append(summary.Regressions, resultKey(result))
+		case !passed && result.Passed:
+			summary.Fixes = append(summary.Fixes, resultKey(result))
+		}
+	}
+
+	sort.Strings(summary.Regressions)
+	sort.Strings(summary.Fixes)
+}
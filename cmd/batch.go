@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"graphql-validation-tool/internal/engine"
+
+	"github.com/chirino/graphql/schema"
+	graphjin "github.com/dosco/graphjin/core"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// batchQueries merges independent read queries into combined GraphJin
+// requests, so each batch costs a single database round trip
+var batchQueries bool
+
+// batchSize caps how many query files share one combined request
+const batchSize = 25
+
+// batchCandidate is a query file whose root fields can be merged into a
+// combined query
+type batchCandidate struct {
+	path   string
+	query  string
+	fields []*schema.FieldSelection
+}
+
+// batchAlias is the response key a candidate's root field is renamed to in
+// the combined query
+func batchAlias(candidate, field int) string {
+	return fmt.Sprintf("b%d_%d", candidate, field)
+}
+
+// runBatches validates the batchable files among queryFiles in combined
+// requests, returning their results keyed by path. Files that cannot be
+// batched, and every file of a batch that fails, are left out so they are
+// validated one by one and their errors attributed to the right file.
+func runBatches(gj *graphjin.GraphJin, queryFiles []string) map[string]TestResult {
+	var candidates []batchCandidate
+	for _, qf := range queryFiles {
+		if c, ok := newBatchCandidate(qf); ok {
+			candidates = append(candidates, c)
+		}
+	}
+
+	results := make(map[string]TestResult, len(candidates))
+	for start := 0; start < len(candidates) && !interrupted(); start += batchSize {
+		end := start + batchSize
+		if end > len(candidates) {
+			end = len(candidates)
+		}
+		for path, result := range runBatch(gj, candidates[start:end]) {
+			results[path] = result
+		}
+	}
+
+	logDebug("Validated %d of %d queries in batches", len(results), len(queryFiles))
+	return results
+}
+
+// newBatchCandidate reports whether a query file can share a request: a
+// single query operation without variables, directives or fragments, whose
+// results do not need per-query SQL
+func newBatchCandidate(path string) (batchCandidate, bool) {
+	if _, ok := inlineQueries[path]; ok || showSQL || maxSQLStatements > 0 {
+		return batchCandidate{}, false
+	}
+	if findVariablesFile(path) != "" {
+		return batchCandidate{}, false
+	}
+	if violations, err := validateVariablesSchema(path, json.RawMessage("{}")); err != nil || len(violations) > 0 {
+		return batchCandidate{}, false
+	}
+
+	query, err := readQueryFile(path)
+	if err != nil {
+		return batchCandidate{}, false
+	}
+	// A shared request cannot be held to one query's time budget
+	if settings, _ := validationOptions().ResolveSettings(engine.ParseHeader(query), ""); settings.Budget > 0 {
+		return batchCandidate{}, false
+	}
+	doc, err := engine.ParseDocument(query)
+	if err != nil || len(doc.Operations) != 1 || len(doc.Fragments) > 0 {
+		return batchCandidate{}, false
+	}
+	// Relationship errors are reported before a query runs, on its own
+	if len(targetSchema.RelationshipErrors(query)) > 0 {
+		return batchCandidate{}, false
+	}
+
+	op := doc.Operations[0]
+	if op.Type != schema.Query || len(op.Vars) > 0 || len(op.Directives) > 0 {
+		return batchCandidate{}, false
+	}
+
+	candidate := batchCandidate{path: path, query: query}
+	for _, sel := range op.Selections {
+		field, ok := sel.(*schema.FieldSelection)
+		if !ok || hasFragmentSpreads(field.Selections) || strings.HasPrefix(field.Name, "__") {
+			return batchCandidate{}, false
+		}
+		candidate.fields = append(candidate.fields, field)
+	}
+	return candidate, len(candidate.fields) > 0
+}
+
+// hasFragmentSpreads reports whether a selection set spreads a named fragment
+func hasFragmentSpreads(sels schema.SelectionList) bool {
+	for _, sel := range sels {
+		switch s := sel.(type) {
+		case *schema.FragmentSpread:
+			return true
+		case *schema.FieldSelection:
+			if hasFragmentSpreads(s.Selections) {
+				return true
+			}
+		case *schema.InlineFragment:
+			if hasFragmentSpreads(s.Selections) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// runBatch runs one combined request and splits its response back into
+// per-file results, or returns nil if the request failed
+func runBatch(gj *graphjin.GraphJin, batch []batchCandidate) map[string]TestResult {
+	var sels schema.SelectionList
+	for i, c := range batch {
+		for j, field := range c.fields {
+			renamed := *field
+			renamed.Alias = batchAlias(i, j)
+			sels = append(sels, &renamed)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("query")
+	engine.WriteSelections(&b, sels, 0)
+
+	ctx, span := tracer.Start(validationCtx, "validate batch", trace.WithAttributes(attribute.Int("gql.batch.size", len(batch))))
+	start := time.Now()
+	res, err := gj.GraphQL(withQueryUser(ctx, ""), b.String(), json.RawMessage("{}"), nil)
+	elapsed := time.Since(start)
+	span.End()
+
+	if err != nil || res == nil || len(res.Errors) > 0 {
+		logDebug("Batch of %d queries failed, validating them one by one", len(batch))
+		return nil
+	}
+
+	var data map[string]json.RawMessage
+	if err := json.Unmarshal(res.Data, &data); err != nil {
+		return nil
+	}
+
+	results := make(map[string]TestResult, len(batch))
+	for i, c := range batch {
+		results[c.path] = recoverValidation(filepath.Base(c.path), c.path, func() TestResult {
+			return batchResult(c, i, data, elapsed/time.Duration(len(batch)))
+		})
+	}
+	return results
+}
+
+// batchResult builds a file's result from its share of a combined response,
+// restoring its original response keys before running the usual checks
+func batchResult(c batchCandidate, index int, data map[string]json.RawMessage, duration time.Duration) TestResult {
+	result := TestResult{
+		Name:      filepath.Base(c.path),
+		Path:      c.path,
+		Operation: "query",
+		Errors:    []string{},
+		Duration:  duration.Milliseconds(),
+	}
+	_, result.OperationName, _ = engine.DescribeOperation(c.query)
+
+	own := make(map[string]json.RawMessage, len(c.fields))
+	for j, field := range c.fields {
+		key := field.Alias
+		if key == "" {
+			key = field.Name
+		}
+		own[key] = data[batchAlias(index, j)]
+	}
+	ownData, _ := json.Marshal(own)
+
+	header := engine.ParseHeader(c.query)
+	settings, settingErrs := validationOptions().ResolveSettings(header, "")
+
+	if msg := engine.CheckResultSize(ownData, settings.MaxResultBytes); msg != "" {
+		result.Errors = append(result.Errors, msg)
+		ownData = nil
+	}
+
+	if !noNestedErrorScan && ownData != nil {
+		result.Errors = append(result.Errors, validationOptions().FindNestedErrors(ownData)...)
+	}
+
+	errs, warnings := splitWarnings(targetSchema.DeprecationWarnings(c.query))
+	result.Errors = append(result.Errors, errs...)
+	result.Errors = append(result.Errors, settingErrs...)
+	result.Warnings = append(header.Warnings(), warnings...)
+
+	if settings.Expect != "" && len(result.Errors) == 0 {
+		assertions, err := engine.CheckExpectation(settings.Expect, ownData)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("Invalid expect header: %v", err))
+		}
+		result.Errors = append(result.Errors, assertions...)
+	}
+
+	if requireDescription && header.Description == "" {
+		result.Errors = append([]string{engine.MissingDescriptionError}, result.Errors...)
+	}
+
+	result.Errors = engine.DedupeErrors(result.Errors)
+	result.Passed = len(result.Errors) == 0
+	return result
+}
@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	benchIterations int
+	benchWarmup     int
+)
+
+// BenchResult represents latency statistics for a benchmarked query
+type BenchResult struct {
+	Name       string  `json:"name"`
+	Path       string  `json:"path"`
+	Iterations int     `json:"iterations"`
+	Warmup     int     `json:"warmup"`
+	MinMs      float64 `json:"min_ms"`
+	MeanMs     float64 `json:"mean_ms"`
+	MedianMs   float64 `json:"median_ms"`
+	P95Ms      float64 `json:"p95_ms"`
+	P99Ms      float64 `json:"p99_ms"`
+	MaxMs      float64 `json:"max_ms"`
+	QPS        float64 `json:"queries_per_second"`
+}
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Benchmark a GraphQL query's latency",
+	Long: `Execute a GraphQL query repeatedly and report latency statistics.
+
+This command runs the query N times against your database (after a
+number of discarded warmup runs) and reports min/mean/median/p95/p99/max
+latency along with queries per second. Variables are loaded from the
+companion .json file, just like the validate command.
+
+Examples:
+  # Benchmark a query 100 times
+  gql-validate bench -f ./queries/get_user.graphql -n 100
+
+  # Use a longer warmup
+  gql-validate bench -f ./queries/get_user.graphql -n 500 --warmup 20
+
+  # Output as JSON
+  gql-validate bench -f ./queries/get_user.graphql -j`,
+	RunE: runBench,
+}
+
+func init() {
+	rootCmd.AddCommand(benchCmd)
+
+	benchCmd.Flags().StringVarP(&queryFile, "file", "f", "", "GraphQL file to benchmark")
+	benchCmd.Flags().IntVarP(&benchIterations, "iterations", "n", 100, "number of measured iterations")
+	benchCmd.Flags().IntVar(&benchWarmup, "warmup", 5, "number of warmup iterations to discard")
+	benchCmd.MarkFlagRequired("file")
+}
+
+func runBench(cmd *cobra.Command, args []string) error {
+	if benchIterations < 1 {
+		return fmt.Errorf("--iterations must be at least 1")
+	}
+	if benchWarmup < 0 {
+		return fmt.Errorf("--warmup cannot be negative")
+	}
+
+	// Load configuration
+	config, err := LoadConfig(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	query, err := readQueryFile(queryFile)
+	if err != nil {
+		return fmt.Errorf("failed to read query file: %w", err)
+	}
+
+	variables, _, err := loadVariables(validationCtx, queryFile)
+	if err != nil {
+		return fmt.Errorf("failed to load variables: %w", err)
+	}
+
+	// Initialize GraphJin
+	gj, db, err := initializeGraphJin(config)
+	if err != nil {
+		return fmt.Errorf("failed to initialize GraphJin: %w", err)
+	}
+	defer db.Close()
+
+	ctx := withQueryUser(context.Background(), "")
+
+	run := func() (time.Duration, error) {
+		start := time.Now()
+		res, err := gj.GraphQL(ctx, query, variables, nil)
+		elapsed := time.Since(start)
+		if err != nil {
+			return elapsed, err
+		}
+		if res != nil && len(res.Errors) > 0 {
+			return elapsed, fmt.Errorf("%s", res.Errors[0].Message)
+		}
+		return elapsed, nil
+	}
+
+	for i := 0; i < benchWarmup; i++ {
+		if _, err := run(); err != nil {
+			return fmt.Errorf("query failed during warmup: %w", err)
+		}
+	}
+
+	durations := make([]time.Duration, 0, benchIterations)
+	total := time.Duration(0)
+
+	for i := 0; i < benchIterations; i++ {
+		elapsed, err := run()
+		if err != nil {
+			return fmt.Errorf("query failed on iteration %d: %w", i+1, err)
+		}
+		durations = append(durations, elapsed)
+		total += elapsed
+	}
+
+	result := computeBenchResult(durations, total)
+	result.Name = filepath.Base(queryFile)
+	result.Path = queryFile
+	result.Warmup = benchWarmup
+
+	if jsonOutput {
+		jsonData, err := marshalOutput(result)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(jsonData))
+		return nil
+	}
+
+	printBenchResult(result)
+	return nil
+}
+
+// computeBenchResult derives latency statistics from the measured durations
+func computeBenchResult(durations []time.Duration, total time.Duration) BenchResult {
+	sorted := make([]time.Duration, len(durations))
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	result := BenchResult{
+		Iterations: len(sorted),
+		MinMs:      toMillis(sorted[0]),
+		MeanMs:     toMillis(total / time.Duration(len(sorted))),
+		MedianMs:   toMillis(percentile(sorted, 50)),
+		P95Ms:      toMillis(percentile(sorted, 95)),
+		P99Ms:      toMillis(percentile(sorted, 99)),
+		MaxMs:      toMillis(sorted[len(sorted)-1]),
+	}
+
+	if total > 0 {
+		result.QPS = float64(len(sorted)) / total.Seconds()
+	}
+
+	return result
+}
+
+// percentile returns the nearest-rank percentile of an ascending slice
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func toMillis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func printBenchResult(result BenchResult) {
+	fmt.Println()
+	fmt.Printf("Benchmark: %s\n", result.Name)
+	fmt.Printf("  %d iterations (%d warmup discarded)\n", result.Iterations, result.Warmup)
+	fmt.Println()
+	fmt.Printf("  min:     %9.2fms\n", result.MinMs)
+	fmt.Printf("  mean:    %9.2fms\n", result.MeanMs)
+	fmt.Printf("  median:  %9.2fms\n", result.MedianMs)
+	fmt.Printf("  p95:     %9.2fms\n", result.P95Ms)
+	fmt.Printf("  p99:     %9.2fms\n", result.P99Ms)
+	fmt.Printf("  max:     %9.2fms\n", result.MaxMs)
+	fmt.Println()
+	fmt.Printf("  throughput: %.1f queries/sec\n", result.QPS)
+	fmt.Println()
+}
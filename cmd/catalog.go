@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"graphql-validation-tool/internal/engine"
+
+	"github.com/chirino/graphql/schema"
+	"github.com/spf13/cobra"
+)
+
+// catalogOffline skips the database lookup that maps queries onto tables
+var catalogOffline bool
+
+// Catalog is a machine-readable index of the query suite
+type Catalog struct {
+	Directory string         `json:"directory"`
+	Queries   []CatalogEntry `json:"queries"`
+}
+
+// CatalogEntry describes one operation of a query file; files defining
+// several operations have one entry per operation
+type CatalogEntry struct {
+	Name          string            `json:"name"`
+	Path          string            `json:"path"`
+	Description   string            `json:"description,omitempty"`
+	Tags          []string          `json:"tags,omitempty"`
+	Operation     string            `json:"operation,omitempty"`
+	OperationName string            `json:"operation_name,omitempty"`
+	Variables     []CatalogVariable `json:"variables"`
+	VariablesFile string            `json:"variables_file,omitempty"`
+	// Tables is only set when the database could be consulted
+	Tables []string `json:"tables,omitempty"`
+	// ParseError is set when the file is not valid GraphQL
+	ParseError string `json:"parse_error,omitempty"`
+}
+
+// CatalogVariable is a variable declared by an operation
+type CatalogVariable struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Required bool   `json:"required"`
+	Default  string `json:"default,omitempty"`
+}
+
+var catalogCmd = &cobra.Command{
+	Use:   "catalog",
+	Short: "Write a JSON catalog of every query",
+	Long: `Write a machine-readable catalog of the query suite for documentation.
+
+Each query is listed with its description, tags, operation type and name,
+and declared variables with their types. When a config file is available,
+the database is consulted to list the tables each query touches; use
+--offline to skip it.
+
+Examples:
+  # Write the catalog to a file
+  gql-validate catalog --out catalog.json
+
+  # Catalog a specific directory without connecting to the database
+  gql-validate catalog -q ./my-queries --offline`,
+	Args: cobra.NoArgs,
+	RunE: runCatalog,
+}
+
+func init() {
+	rootCmd.AddCommand(catalogCmd)
+
+	catalogCmd.Flags().StringVarP(&queriesDir, "queries", "q", "./queries", "directory containing GraphQL query files")
+	catalogCmd.Flags().StringVarP(&outFile, "out", "o", "", "write the catalog to this file instead of stdout")
+	catalogCmd.Flags().BoolVar(&catalogOffline, "offline", false, "do not connect to the database, leaving out the tables each query touches")
+}
+
+func runCatalog(cmd *cobra.Command, args []string) error {
+	config, err := LoadOptionalConfig(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	applyQueriesConfig(cmd, config)
+
+	queryFiles, err := discoverQueryFiles(queriesDir)
+	if err != nil {
+		return err
+	}
+	queryFiles = excludePartialFiles(queryFiles)
+
+	if len(queryFiles) == 0 {
+		logWarn("No query files found")
+		return nil
+	}
+
+	var tables map[string][]string
+	if !catalogOffline {
+		tables = catalogTables(config)
+	}
+
+	catalog := Catalog{Directory: queriesDir, Queries: []CatalogEntry{}}
+	for _, qf := range queryFiles {
+		catalog.Queries = append(catalog.Queries, catalogEntries(qf, tables)...)
+	}
+
+	return withResultsOutput(func() error {
+		jsonData, err := marshalOutput(catalog)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(resultsOutput, string(jsonData))
+		return nil
+	})
+}
+
+// catalogTables loads the database's tables for mapping queries onto them,
+// returning nil with a warning when there is no usable database
+func catalogTables(config *Config) map[string][]string {
+	if config == nil {
+		logInfo("No config file found, leaving tables out of the catalog")
+		return nil
+	}
+	if err := config.Validate(); err != nil {
+		logWarn("Leaving tables out of the catalog: invalid configuration: %v", err)
+		return nil
+	}
+
+	db, err := sql.Open("pgx", config.GetDSN())
+	if err != nil {
+		logWarn("Leaving tables out of the catalog: %v", redactError(err))
+		return nil
+	}
+	defer db.Close()
+
+	tables, err := engine.LoadTableColumns(db, config.Database.Schema)
+	if err != nil {
+		logWarn("Leaving tables out of the catalog: %v", redactError(err))
+		return nil
+	}
+	return tables
+}
+
+// catalogEntries describes each operation of a query file
+func catalogEntries(path string, tables map[string][]string) []CatalogEntry {
+	base := CatalogEntry{
+		Name:          filepath.Base(path),
+		Path:          path,
+		Variables:     []CatalogVariable{},
+		VariablesFile: findVariablesFile(path),
+	}
+
+	content, err := readQueryFile(path)
+	if err != nil {
+		// Report the raw file's header even if an include is broken
+		content, _ = engine.ReadQuerySource(path)
+		base.ParseError = err.Error()
+	}
+	header := engine.ParseHeader(content)
+	base.Description = header.Description
+	base.Tags = header.Tags
+	if base.ParseError != "" {
+		return []CatalogEntry{base}
+	}
+
+	doc, err := engine.ParseDocument(content)
+	if err != nil {
+		base.ParseError = err.Error()
+		return []CatalogEntry{base}
+	}
+
+	entries := make([]CatalogEntry, 0, len(doc.Operations))
+	for _, op := range doc.Operations {
+		entry := base
+		entry.Operation = string(op.Type)
+		entry.OperationName = engine.DisplayOperationName(op.Name)
+		entry.Variables = catalogVariables(op.Vars)
+
+		if tables != nil {
+			referenced := make(map[string]map[string]bool)
+			engine.CollectColumnRefs(doc, op.Selections, "", tables, referenced)
+			for table := range referenced {
+				entry.Tables = append(entry.Tables, table)
+			}
+			sort.Strings(entry.Tables)
+		}
+
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// catalogVariables describes an operation's declared variables
+func catalogVariables(vars schema.InputValueList) []CatalogVariable {
+	described := make([]CatalogVariable, 0, len(vars))
+	for _, v := range vars {
+		_, nonNull := v.Type.(*schema.NonNull)
+		variable := CatalogVariable{
+			Name:     strings.TrimPrefix(v.Name, "$"),
+			Type:     v.Type.String(),
+			Required: nonNull && v.Default == nil,
+		}
+		if v.Default != nil {
+			variable.Default = v.Default.String()
+		}
+		described = append(described, variable)
+	}
+	return described
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"graphql-validation-tool/internal/engine"
+)
+
+var (
+	// changedOnly limits validation to queries changed since baseRef
+	changedOnly bool
+	baseRef     string
+)
+
+// gitChangedFiles returns the absolute paths of the files in dir's git
+// repository changed since the merge base of baseRef and HEAD, including
+// uncommitted and untracked files. ok is false when dir is not inside a git
+// repository.
+func gitChangedFiles(dir, baseRef string) (changed map[string]bool, ok bool, err error) {
+	git := func(args ...string) ([]byte, error) {
+		return exec.Command("git", append([]string{"-C", dir}, args...)...).Output()
+	}
+
+	out, err := git("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, false, nil
+	}
+	root := strings.TrimSpace(string(out))
+
+	out, err = git("merge-base", baseRef, "HEAD")
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to find the merge base with %s: %w", baseRef, err)
+	}
+	mergeBase := strings.TrimSpace(string(out))
+
+	diff, err := git("diff", "--name-only", mergeBase)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to list changed files: %w", err)
+	}
+	untracked, err := git("ls-files", "--others", "--exclude-standard", "--full-name", root)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to list untracked files: %w", err)
+	}
+
+	changed = make(map[string]bool)
+	for _, name := range strings.Split(string(diff)+string(untracked), "\n") {
+		if name = strings.TrimSpace(name); name != "" {
+			changed[filepath.Join(root, name)] = true
+		}
+	}
+	return changed, true, nil
+}
+
+// queryChanged reports whether a query file or one of its variables files is
+// in the changed set
+func queryChanged(path string, changed map[string]bool) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	// git reports paths under the resolved repository root
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+
+	base := queryBasePath(abs)
+	candidates := []string{abs, base + ".json", base + engine.VariablesYAMLSuffix, base + ".schema.json"}
+	if variablesEnv != "" {
+		candidates = append(candidates, base+"."+variablesEnv+".json", base+"."+variablesEnv+engine.VariablesYAMLSuffix)
+	}
+
+	for _, candidate := range candidates {
+		if changed[candidate] {
+			return true
+		}
+	}
+	return false
+}
+
+// selectChangedFiles keeps the query files changed since baseRef, or all of
+// them when not running inside a git repository
+func selectChangedFiles(queryFiles []string) ([]string, error) {
+	changed, ok, err := gitChangedFiles(queriesDirs[0], baseRef)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		logWarn("Not in a git repository, validating all queries")
+		return queryFiles, nil
+	}
+
+	selected := queryFiles[:0]
+	for _, qf := range queryFiles {
+		if queryChanged(qf, changed) {
+			selected = append(selected, qf)
+		}
+	}
+	logInfo("Validating %d query(s) changed since %s", len(selected), baseRef)
+	return selected, nil
+}
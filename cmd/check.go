@@ -1,10 +1,14 @@
 package cmd
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
+	"graphql-validation-tool/internal/engine"
+
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/spf13/cobra"
 )
@@ -20,97 +24,188 @@ credentials and reports the connection status.
 Examples:
   # Check connection using default config
   gql-validate check
-aml
   # Check connection with custom config
   gql-validate check -c /path/to/config.yaml
 
   # Check with verbose output
-  gql-validate check -v`,
+  gql-validate check -v
+
+  # Give up on an unreachable host after 5 seconds
+  gql-validate check --timeout 5s
+
+  # Also confirm GraphJin can load the schema and run a query
+  gql-validate check --smoke`,
 	RunE: runCheck,
 }
 
+var (
+	checkSmoke bool
+	// checkTimeout bounds the connection test and schema queries
+	checkTimeout time.Duration
+)
+
 func init() {
 	rootCmd.AddCommand(checkCmd)
+
+	checkCmd.Flags().BoolVar(&checkSmoke, "smoke", false, "initialize GraphJin and run a trivial query end-to-end")
+	checkCmd.Flags().DurationVar(&checkTimeout, "timeout", 30*time.Second, "fail if connecting and querying the database takes longer than this (0 disables)")
 }
 
 func runCheck(cmd *cobra.Command, args []string) error {
-	fmt.Println("Checking configuration and database connection...")
-	fmt.Println()
+	logInfo("Checking configuration and database connection...\n")
 
 	// Load configuration
-	fmt.Printf("  ○ Loading config from: %s\n", cfgFile)
+	logInfo("  ○ Loading config from: %s", describeConfigPath(cfgFile))
 	config, err := LoadConfig(cfgFile)
 	if err != nil {
-		fmt.Printf("  ✗ Failed to load config: %v\n", err)
+		logError("  ✗ Failed to load config: %v", err)
 		return err
 	}
-	fmt.Printf("  ✓ Config loaded successfully\n")
+	logInfo("  ✓ Config loaded successfully")
 
 	// validating the  configuration
-	fmt.Printf("  ○ Validating configuration...\n")
+	logInfo("  ○ Validating configuration...")
 	if err := config.Validate(); err != nil {
-		fmt.Printf("  ✗ Invalid configuration: %v\n", err)
+		logError("  ✗ Invalid configuration: %v", err)
 		return err
 	}
-	fmt.Printf("  ✓ Configuration is valid\n")
+	logInfo("  ✓ Configuration is valid")
 
 	// Print connection details (hide password)
-	if verbose {
-		fmt.Println()
-		fmt.Println("  Connection Details:")
-		fmt.Printf("    Host:     %s\n", config.Database.Host)
-		fmt.Printf("    Port:     %d\n", config.Database.Port)
-		fmt.Printf("    Database: %s\n", config.Database.DBName)
-		fmt.Printf("    User:     %s\n", config.Database.User)
-		fmt.Printf("    SSL Mode: %s\n", config.Database.SSLMode)
-		fmt.Println()
+	logDebug("")
+	logDebug("  Connection Details:")
+	if config.Database.URL != "" {
+		logDebug("    URL:      %s", redactedDSN(config.Database.URL))
+	} else {
+		logDebug("    Host:     %s", config.Database.Host)
+		logDebug("    Port:     %d", config.Database.Port)
+		logDebug("    Database: %s", config.Database.DBName)
+		logDebug("    User:     %s", config.Database.User)
+		logDebug("    SSL Mode: %s", config.Database.SSLMode)
 	}
+	logDebug("    Schema:   %s", config.Database.Schema)
+	logDebug("")
 
 	// Test database connection
-	fmt.Printf("  ○ Connecting to database...\n")
+	logInfo("  ○ Connecting to database...")
 	start := time.Now()
 
+	ctx := context.Background()
+	if checkTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, checkTimeout)
+		defer cancel()
+	}
+
 	db, err := sql.Open("pgx", config.GetDSN())
 	if err != nil {
-		fmt.Printf("  ✗ Failed to open database connection: %v\n", err)
+		err = redactError(err)
+		logError("  ✗ Failed to open database connection: %v", err)
 		return err
 	}
 	defer db.Close()
 
 	// Ping the database
-	if err := db.Ping(); err != nil {
-		fmt.Printf("  ✗ Failed to connect to database: %v\n", err)
+	if err := db.PingContext(ctx); err != nil {
+		err = checkTimeoutError(ctx, redactError(err))
+		logError("  ✗ Failed to connect to database: %v", err)
 		return err
 	}
 
 	elapsed := time.Since(start)
-	fmt.Printf("  ✓ Database connection successful (%dms)\n", elapsed.Milliseconds())
+	logInfo("  ✓ Database connection successful (%dms)", elapsed.Milliseconds())
 
 	// Get database version
 	var version string
-	err = db.QueryRow("SELECT version()").Scan(&version)
-	if err == nil && verbose {
-		fmt.Printf("  ✓ Database version: %s\n", truncateString(version, 60))
+	err = db.QueryRowContext(ctx, "SELECT version()").Scan(&version)
+	if err == nil {
+		logDebug("  ✓ Database version: %s", truncateString(version, 60))
 	}
 
 	// Check tables count
 	var tableCount int
-	err = db.QueryRow(`
+	err = db.QueryRowContext(ctx, `
 		SELECT COUNT(*)
 		FROM information_schema.tables
-		WHERE table_schema = 'public'
-	`).Scan(&tableCount)
+		WHERE table_schema = $1
+	`, config.Database.Schema).Scan(&tableCount)
 	if err == nil {
-		fmt.Printf("  ✓ Found %d table(s) in public schema\n", tableCount)
+		logInfo("  ✓ Found %d table(s) in %s schema", tableCount, config.Database.Schema)
+	}
+	if ctx.Err() != nil {
+		err = checkTimeoutError(ctx, err)
+		logError("  ✗ Failed to inspect the schema: %v", err)
+		return err
+	}
+
+	if checkSmoke {
+		logInfo("  ○ Running GraphJin smoke test...")
+		start := time.Now()
+		if err := runSmokeTest(ctx, config); err != nil {
+			logError("  ✗ GraphJin smoke test failed: %v", err)
+			return err
+		}
+		logInfo("  ✓ GraphJin loaded the schema and ran a query (%dms)", time.Since(start).Milliseconds())
+	}
+
+	logInfo("")
+	logInfo("All checks passed! Your configuration is ready to use.")
+	logInfo("")
+
+	return nil
+}
+
+// runSmokeTest initializes GraphJin, which reflects the database schema,
+// and runs an introspection query through it to confirm the full stack
+// works. GraphJin's schema load cannot be cancelled, so the test is given
+// up on, rather than stopped, when ctx ends first.
+func runSmokeTest(ctx context.Context, config *Config) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- smokeTest(ctx, config)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return checkTimeoutError(ctx, err)
+		}
+		return nil
+	case <-ctx.Done():
+		return checkTimeoutError(ctx, ctx.Err())
+	}
+}
+
+func smokeTest(ctx context.Context, config *Config) error {
+	gj, db, err := initializeGraphJinContext(ctx, config)
+	if err != nil {
+		return err
 	}
+	defer db.Close()
 
-	fmt.Println()
-	fmt.Println("All checks passed! Your configuration is ready to use.")
-	fmt.Println()
+	res, err := gj.GraphQL(ctx, engine.WarmUpQuery, nil, nil)
+	if err != nil {
+		return fmt.Errorf("query failed: %w", err)
+	}
+	if len(res.Errors) > 0 {
+		return fmt.Errorf("query failed: %s", res.Errors[0].Message)
+	}
+	if len(res.Data) == 0 {
+		return fmt.Errorf("query returned no data")
+	}
 
 	return nil
 }
 
+// checkTimeoutError replaces an error caused by the --timeout deadline
+// with a message saying so
+func checkTimeoutError(ctx context.Context, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("connection timed out after %s", checkTimeout)
+	}
+	return err
+}
+
 func truncateString(s string, maxLen int) string {
 	if len(s) <= maxLen {
 		return s
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"os"
+)
+
+// ANSI escape sequences used for colored output
+const (
+	ansiReset = "\033[0m"
+	ansiRed   = "\033[31m"
+	ansiGreen = "\033[32m"
+	ansiDim   = "\033[2m"
+)
+
+// isTerminal reports whether the given file is attached to a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// useColor reports whether colored output should be written to the results.
+// Color is disabled by --no-color, the NO_COLOR env var, or when results are
+// not written to a terminal.
+func useColor() bool {
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return resultsTTY()
+}
+
+func colorize(code, s string) string {
+	if !useColor() {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+func green(s string) string {
+	return colorize(ansiGreen, s)
+}
+
+func red(s string) string {
+	return colorize(ansiRed, s)
+}
+
+func dim(s string) string {
+	return colorize(ansiDim, s)
+}
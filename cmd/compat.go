@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"fmt"
+
+	"graphql-validation-tool/internal/engine"
+
+	"github.com/spf13/cobra"
+)
+
+// Schema compatibility categories
+const (
+	categoryMissingTable  = engine.CategoryMissingTable
+	categoryMissingColumn = engine.CategoryMissingColumn
+	categoryTypeMismatch  = engine.CategoryTypeMismatch
+	categoryOther         = engine.CategoryOther
+)
+
+// compatCategories lists the categories in report order
+var compatCategories = []string{
+	categoryMissingTable,
+	categoryMissingColumn,
+	categoryTypeMismatch,
+	categoryOther,
+}
+
+var compatCategoryTitles = map[string]string{
+	categoryMissingTable:  "Missing table",
+	categoryMissingColumn: "Missing column",
+	categoryTypeMismatch:  "Type mismatch",
+	categoryOther:         "Other",
+}
+
+// CompatIssue represents a single incompatibility found in a query
+type CompatIssue struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// CompatReport groups query incompatibilities by category
+type CompatReport struct {
+	Total        int                      `json:"total"`
+	Compatible   int                      `json:"compatible"`
+	Incompatible int                      `json:"incompatible"`
+	Categories   map[string][]CompatIssue `json:"categories"`
+}
+
+var compatCmd = &cobra.Command{
+	Use:   "compat",
+	Short: "Report schema compatibility issues grouped by cause",
+	Long: `Compile every query against the database and group failures by cause.
+
+Each error is classified as a missing table, missing column, type
+mismatch, or other problem by inspecting the GraphJin error text. This
+triage view shows at a glance how a schema migration affects your queries.
+
+Examples:
+  # Report compatibility of all queries in the default directory
+  gql-validate compat
+
+  # Check a specific directory
+  gql-validate compat -q ./my-queries
+
+  # Output as JSON
+  gql-validate compat -j`,
+	RunE: runCompat,
+}
+
+func init() {
+	rootCmd.AddCommand(compatCmd)
+
+	compatCmd.Flags().StringVarP(&queriesDir, "queries", "q", "./queries", "directory containing GraphQL query files")
+}
+
+func runCompat(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	config, err := LoadConfig(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	queryFiles, err := discoverQueryFiles(queriesDir)
+	if err != nil {
+		return err
+	}
+	queryFiles = excludePartialFiles(queryFiles)
+
+	if len(queryFiles) == 0 {
+		logWarn("No query files found")
+		return nil
+	}
+
+	// Initialize GraphJin
+	gj, db, err := initializeGraphJin(config)
+	if err != nil {
+		return fmt.Errorf("failed to initialize GraphJin: %w", err)
+	}
+	defer db.Close()
+
+	report := CompatReport{
+		Total:      len(queryFiles),
+		Categories: make(map[string][]CompatIssue),
+	}
+
+	for _, qf := range queryFiles {
+		result := validateQuerySafely(gj, qf, "")
+		if result.Passed {
+			report.Compatible++
+			continue
+		}
+
+		report.Incompatible++
+		for _, msg := range result.Errors {
+			category := engine.ClassifyError(msg)
+			report.Categories[category] = append(report.Categories[category], CompatIssue{
+				Name:    result.Name,
+				Path:    result.Path,
+				Message: msg,
+			})
+		}
+	}
+
+	if jsonOutput {
+		jsonData, err := marshalOutput(report)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(jsonData))
+	} else {
+		printCompatReport(report)
+	}
+
+	if report.Incompatible > 0 {
+		return fmt.Errorf("%d query(s) incompatible with the current schema", report.Incompatible)
+	}
+
+	return nil
+}
+
+func printCompatReport(report CompatReport) {
+	fmt.Println()
+	fmt.Println("Schema Compatibility Report")
+	fmt.Println()
+
+	for _, category := range compatCategories {
+		issues := report.Categories[category]
+		if len(issues) == 0 {
+			continue
+		}
+
+		fmt.Printf("  %s (%d)\n", red(compatCategoryTitles[category]), len(issues))
+		for _, issue := range issues {
+			fmt.Printf("    %-40s %s\n", issue.Name, issue.Message)
+		}
+		fmt.Println()
+	}
+
+	if report.Incompatible == 0 {
+		fmt.Println(green(fmt.Sprintf("  ✓ All %d queries are compatible with the current schema", report.Total)))
+	} else {
+		fmt.Printf("  Summary: %d total, %s, %s\n",
+			report.Total,
+			green(fmt.Sprintf("%d compatible", report.Compatible)),
+			red(fmt.Sprintf("%d incompatible", report.Incompatible)))
+	}
+	fmt.Println()
+}
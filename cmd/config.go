@@ -1,88 +1,101 @@
-	package cmd
+package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 
-	"gopkg.in/yaml.v2"
+	"graphql-validation-tool/internal/engine"
 )
 
-type Config struct {
-	Database struct {
-		Type     string `yaml:"type"`
-		Host     string `yaml:"host"`
-		Port     int    `yaml:"port"`
-		DBName   string `yaml:"dbname"`
-		User     string `yaml:"user"`
-		Password string `yaml:"password"`
-		SSLMode  string `yaml:"sslmode"`
-	} `yaml:"database"`
-	Production bool `yaml:"production"`
-}
+// The configuration types live in the engine package, shared with the
+// validator package
+type (
+	Config         = engine.Config
+	DatabaseConfig = engine.DatabaseConfig
+)
+
+const (
+	// stdinConfigPath is the config path that reads the config from stdin
+	stdinConfigPath = "-"
+
+	// configEnvVar holds the raw config YAML, used when --config is not given
+	configEnvVar = "GQLVALIDATE_CONFIG"
 
-// LoadConfig reads and parses the config file, with environment variable overrides
+	// envConfigPath is the config path that reads the config from configEnvVar
+	envConfigPath = "$" + configEnvVar
+)
+
+// stdinConfig caches the config read from stdin, which can only be read once
+var stdinConfig []byte
+
+// LoadConfig reads and parses the config file, with environment variable
+// overrides. A path of "-" reads the config from stdin.
 func LoadConfig(configPath string) (*Config, error) {
-	data, err := os.ReadFile(configPath)
+	data, err := readConfig(configPath)
+	if errors.Is(err, os.ErrNotExist) && (dsnOverride != "" || os.Getenv("DB_URL") != "") {
+		// A connection URL is enough to run without a config file
+		data, err = nil, nil
+	}
 	if err != nil {
 		return nil, fmt.Errorf("could not read config file: %w", err)
 	}
 
-	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("could not parse config file: %w", err)
+	config, err := engine.ParseConfigData(data)
+	if err != nil {
+		return nil, err
 	}
 
-	// Override with environment variables if set
-	config.Database.Host = getEnv("DB_HOST", config.Database.Host)
-	config.Database.DBName = getEnv("DB_NAME", config.Database.DBName)
-	config.Database.User = getEnv("DB_USER", config.Database.User)
-	config.Database.Password = getEnv("DB_PASSWORD", config.Database.Password)
-	config.Database.SSLMode = getEnv("DB_SSLMODE", config.Database.SSLMode)
-
-	// Also check for DB_PORT as environment variable
-	if portStr := os.Getenv("DB_PORT"); portStr != "" {
-		var port int
-		if _, err := fmt.Sscanf(portStr, "%d", &port); err == nil {
-			config.Database.Port = port
-		}
+	// --dsn takes precedence over the config and environment
+	if dsnOverride != "" {
+		config.Database.URL = dsnOverride
 	}
 
-	return &config, nil
+	registerSecrets(config.Database)
+
+	return config, nil
 }
 
-// getEnv returns environment variable value or default if not set
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// readConfig returns the raw config YAML from a file, stdin or configEnvVar
+func readConfig(configPath string) ([]byte, error) {
+	switch configPath {
+	case stdinConfigPath:
+		if stdinConfig == nil {
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return nil, err
+			}
+			stdinConfig = data
+		}
+		return stdinConfig, nil
+	case envConfigPath:
+		return []byte(os.Getenv(configEnvVar)), nil
+	default:
+		return os.ReadFile(configPath)
 	}
-	return defaultValue
 }
 
-// GetDSN returns the PostgreSQL connection string
-func (c *Config) GetDSN() string {
-	return fmt.Sprintf("host=%s port=%d dbname=%s user=%s password=%s sslmode=%s",
-		c.Database.Host,
-		c.Database.Port,
-		c.Database.DBName,
-		c.Database.User,
-		c.Database.Password,
-		c.Database.SSLMode,
-	)
+// describeConfigPath names where a config path reads the config from
+func describeConfigPath(configPath string) string {
+	switch configPath {
+	case stdinConfigPath:
+		return "stdin"
+	case envConfigPath:
+		return configEnvVar
+	default:
+		return configPath
+	}
 }
 
-// Validate checks if the configuration has all required fields
-func (c *Config) Validate() error {
-	if c.Database.Host == "" {
-		return fmt.Errorf("database host is required")
-	}
-	if c.Database.Port == 0 {
-		return fmt.Errorf("database port is required")
-	}
-	if c.Database.DBName == "" {
-		return fmt.Errorf("database name is required")
+// LoadOptionalConfig loads the config file like LoadConfig, but returns nil
+// without an error when the file does not exist
+func LoadOptionalConfig(configPath string) (*Config, error) {
+	if configPath == stdinConfigPath || configPath == envConfigPath {
+		return LoadConfig(configPath)
 	}
-	if c.Database.User == "" {
-		return fmt.Errorf("database user is required")
+	if _, err := os.Stat(configPath); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
 	}
-	return nil
+	return LoadConfig(configPath)
 }
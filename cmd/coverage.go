@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"graphql-validation-tool/internal/engine"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/spf13/cobra"
+)
+
+// TableCoverage represents how many of a table's columns are referenced by queries
+type TableCoverage struct {
+	Table     string   `json:"table"`
+	Columns   int      `json:"columns"`
+	Covered   int      `json:"covered"`
+	Fraction  float64  `json:"fraction"`
+	Uncovered []string `json:"uncovered_columns,omitempty"`
+}
+
+// CoverageReport represents schema coverage across the whole query suite
+type CoverageReport struct {
+	Queries         int             `json:"queries"`
+	Tables          []TableCoverage `json:"tables"`
+	UntouchedTables []string        `json:"untouched_tables"`
+}
+
+var coverageCmd = &cobra.Command{
+	Use:   "coverage",
+	Short: "Report which tables and columns the queries reference",
+	Long: `Report how much of the database schema is exercised by the query suite.
+
+This command parses the field selections of every query, maps them onto
+the tables and columns of your database, and reports for each table the
+fraction of columns referenced by at least one query, along with the
+tables no query touches.
+
+Examples:
+  # Report coverage for the default queries directory
+  gql-validate coverage
+
+  # Report coverage for a specific directory
+  gql-validate coverage -q ./my-queries
+
+  # Output as JSON
+  gql-validate coverage -j`,
+	RunE: runCoverage,
+}
+
+func init() {
+	rootCmd.AddCommand(coverageCmd)
+
+	coverageCmd.Flags().StringVarP(&queriesDir, "queries", "q", "./queries", "directory containing GraphQL query files")
+}
+
+func runCoverage(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	config, err := LoadConfig(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	queryFiles, err := discoverQueryFiles(queriesDir)
+	if err != nil {
+		return err
+	}
+	queryFiles = excludePartialFiles(queryFiles)
+
+	if len(queryFiles) == 0 {
+		logWarn("No query files found")
+		return nil
+	}
+
+	db, err := sql.Open("pgx", config.GetDSN())
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", redactError(err))
+	}
+	defer db.Close()
+
+	tables, err := engine.LoadTableColumns(db, config.Database.Schema)
+	if err != nil {
+		return fmt.Errorf("failed to load schema: %w", err)
+	}
+
+	// Collect referenced columns per table
+	referenced := make(map[string]map[string]bool)
+	for _, qf := range queryFiles {
+		content, err := readQueryFile(qf)
+		if err != nil {
+			logWarn("Skipping %s: %v", qf, err)
+			continue
+		}
+
+		doc, err := engine.ParseDocument(content)
+		if err != nil {
+			logWarn("Skipping %s: %v", qf, err)
+			continue
+		}
+
+		for _, op := range doc.Operations {
+			engine.CollectColumnRefs(doc, op.Selections, "", tables, referenced)
+		}
+	}
+
+	report := buildCoverageReport(len(queryFiles), tables, referenced)
+
+	if jsonOutput {
+		jsonData, err := marshalOutput(report)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(jsonData))
+		return nil
+	}
+
+	printCoverageReport(report)
+	return nil
+}
+
+func buildCoverageReport(queries int, tables map[string][]string, referenced map[string]map[string]bool) CoverageReport {
+	report := CoverageReport{
+		Queries:         queries,
+		Tables:          []TableCoverage{},
+		UntouchedTables: []string{},
+	}
+
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		refs, touched := referenced[name]
+		if !touched {
+			report.UntouchedTables = append(report.UntouchedTables, name)
+		}
+
+		columns := tables[name]
+		coverage := TableCoverage{
+			Table:   name,
+			Columns: len(columns),
+		}
+		for _, column := range columns {
+			if refs[column] {
+				coverage.Covered++
+			} else {
+				coverage.Uncovered = append(coverage.Uncovered, column)
+			}
+		}
+		if coverage.Columns > 0 {
+			coverage.Fraction = float64(coverage.Covered) / float64(coverage.Columns)
+		}
+
+		report.Tables = append(report.Tables, coverage)
+	}
+
+	return report
+}
+
+func printCoverageReport(report CoverageReport) {
+	fmt.Println()
+	fmt.Printf("Schema Coverage (%d queries)\n", report.Queries)
+	fmt.Println()
+
+	for _, t := range report.Tables {
+		percent := fmt.Sprintf("%5.1f%%", t.Fraction*100)
+		switch {
+		case t.Covered == t.Columns:
+			percent = green(percent)
+		case t.Covered == 0:
+			percent = red(percent)
+		}
+		fmt.Printf("  %-30s %s  (%d/%d columns)\n", t.Table, percent, t.Covered, t.Columns)
+		if verbose && len(t.Uncovered) > 0 {
+			fmt.Printf("     └─ Uncovered: %v\n", t.Uncovered)
+		}
+	}
+
+	fmt.Println()
+	if len(report.UntouchedTables) == 0 {
+		fmt.Println(green("  ✓ Every table is referenced by at least one query"))
+	} else {
+		fmt.Printf("  %d table(s) never referenced:\n", len(report.UntouchedTables))
+		for _, name := range report.UntouchedTables {
+			fmt.Printf("    - %s\n", name)
+		}
+	}
+	fmt.Println()
+}
@@ -0,0 +1,272 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose the setup and suggest fixes",
+	Long: `Run every setup check and print a checklist with a hint for each
+problem found.
+
+Unlike check, which stops at the first problem, doctor goes through the
+config file, its required settings, the environment variables that override
+it, the database connection, GraphJin's schema load, the queries directory
+and every variables file, then exits non-zero if any of them failed.
+
+Examples:
+  # Diagnose the default setup
+  gql-validate doctor
+
+  # Diagnose another config and queries directory
+  gql-validate doctor -c ./ci/config.yaml -q ./graphql`,
+	Args: cobra.NoArgs,
+	RunE: runDoctor,
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+
+	doctorCmd.Flags().StringVarP(&queriesDir, "queries", "q", "./queries", "directory containing GraphQL query files")
+	doctorCmd.Flags().DurationVar(&checkTimeout, "timeout", 30*time.Second, "fail if connecting and querying the database takes longer than this (0 disables)")
+}
+
+// overrideEnvVars are the environment variables that override the config's
+// database settings
+var overrideEnvVars = []string{
+	"DB_URL", "DB_HOST", "DB_PORT", "DB_NAME", "DB_USER", "DB_PASSWORD", "DB_SSLMODE",
+	"PGHOST", "PGPORT", "PGDATABASE", "PGUSER", "PGPASSWORD", "PGSSLMODE",
+	configEnvVar,
+}
+
+// doctorReport prints the checklist and counts the failed checks
+type doctorReport struct {
+	failures int
+}
+
+func (r *doctorReport) pass(format string, args ...interface{}) {
+	fmt.Printf("  %s %s\n", green("✓"), fmt.Sprintf(format, args...))
+}
+
+func (r *doctorReport) info(format string, args ...interface{}) {
+	fmt.Printf("  %s %s\n", dim("○"), fmt.Sprintf(format, args...))
+}
+
+// warn reports a problem that does not fail the run
+func (r *doctorReport) warn(what, hint string) {
+	fmt.Printf("  ! %s\n", what)
+	fmt.Printf("      %s\n", dim("→ "+hint))
+}
+
+func (r *doctorReport) fail(what string, err error, hint string) {
+	r.failures++
+	fmt.Printf("  %s %s: %v\n", red("✗"), what, err)
+	fmt.Printf("      %s\n", dim("→ "+hint))
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	var report doctorReport
+	fmt.Printf("Diagnosing the setup...\n\n")
+
+	config := report.checkConfig()
+	report.checkEnvironment()
+
+	if config != nil {
+		if report.checkDatabase(config) {
+			report.checkGraphJin(config)
+		} else {
+			report.info("GraphJin schema load skipped until the database is reachable")
+		}
+	} else {
+		report.info("Database checks skipped until the config loads")
+	}
+
+	applyQueriesConfig(cmd, config)
+	report.checkVariables(report.checkQueries())
+
+	fmt.Println()
+	if report.failures > 0 {
+		return fmt.Errorf("%d check(s) failed", report.failures)
+	}
+	fmt.Println("Everything looks good. Run 'gql-validate validate' to check your queries.")
+	return nil
+}
+
+// checkConfig loads and validates the config, returning it only when both
+// succeed
+func (r *doctorReport) checkConfig() *Config {
+	path := describeConfigPath(cfgFile)
+	if cfgFile != stdinConfigPath && cfgFile != envConfigPath {
+		if _, err := os.Stat(cfgFile); errors.Is(err, os.ErrNotExist) {
+			if dsnOverride == "" && os.Getenv("DB_URL") == "" {
+				r.fail("Config file", fmt.Errorf("%s not found", path),
+					"run 'gql-validate init' to create one, pass -c with its path, or set DB_URL")
+				return nil
+			}
+			r.info("No config file at %s, connecting with --dsn or DB_URL", path)
+		}
+	}
+
+	config, err := LoadConfig(cfgFile)
+	if err != nil {
+		r.fail("Config file", err, "check the YAML syntax and key names against the README; unknown keys are rejected")
+		return nil
+	}
+	r.pass("Config loaded from %s", path)
+
+	if err := config.Validate(); err != nil {
+		r.fail("Required settings", err,
+			"set the database fields in the config, or DB_HOST, DB_PORT, DB_NAME and DB_USER (or just DB_URL)")
+		return nil
+	}
+	r.pass("Required settings are present")
+	return config
+}
+
+// checkEnvironment lists the variables overriding the config, without their
+// values
+func (r *doctorReport) checkEnvironment() {
+	var set []string
+	for _, name := range overrideEnvVars {
+		if os.Getenv(name) != "" {
+			set = append(set, name)
+		}
+	}
+	if len(set) == 0 {
+		r.info("No DB_* or PG* environment variables set, using the config file as is")
+		return
+	}
+	r.info("Environment overrides: %s", strings.Join(set, ", "))
+}
+
+// checkDatabase connects to the database and counts the tables in its
+// schema, reporting whether it is reachable
+func (r *doctorReport) checkDatabase(config *Config) bool {
+	ctx := context.Background()
+	if checkTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, checkTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	db, err := sql.Open("pgx", config.GetDSN())
+	if err != nil {
+		r.fail("Database connection", redactError(err), "check the connection URL's format")
+		return false
+	}
+	defer db.Close()
+
+	if err := db.PingContext(ctx); err != nil {
+		err = checkTimeoutError(ctx, redactError(err))
+		r.fail("Database connection", err, connectionHint(err))
+		return false
+	}
+	r.pass("Database reachable (%dms)", time.Since(start).Milliseconds())
+
+	var tableCount int
+	err = db.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM information_schema.tables
+		WHERE table_schema = $1
+	`, config.Database.Schema).Scan(&tableCount)
+	switch {
+	case err != nil:
+		r.warn(fmt.Sprintf("Could not list the tables: %v", redactError(err)), "the user needs read access to information_schema")
+	case tableCount == 0:
+		r.warn(fmt.Sprintf("No tables in the %s schema", config.Database.Schema), "set database.schema to the schema your tables are in")
+	default:
+		r.pass("Found %d table(s) in the %s schema", tableCount, config.Database.Schema)
+	}
+	return true
+}
+
+// connectionHint suggests a fix for a failed connection attempt
+func connectionHint(err error) string {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "timed out"):
+		return "the host did not answer; check the host, firewall rules and VPN, or raise --timeout"
+	case strings.Contains(msg, "connection refused"):
+		return "nothing is listening there; check that PostgreSQL is running and the host and port are right"
+	case strings.Contains(msg, "no such host"):
+		return "the host name does not resolve; check the host setting"
+	case strings.Contains(msg, "password authentication failed"):
+		return "check the user and password (DB_PASSWORD, password_file or password_command)"
+	case strings.Contains(msg, "does not exist"):
+		return "check the database name and user, or create them"
+	case strings.Contains(msg, "SSL"), strings.Contains(msg, "tls"):
+		return "set sslmode to match what the server supports"
+	default:
+		return "run 'gql-validate check -v' to see the connection details in use"
+	}
+}
+
+// checkGraphJin loads the schema into GraphJin and runs a query through it
+func (r *doctorReport) checkGraphJin(config *Config) {
+	ctx := context.Background()
+	if checkTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, checkTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	if err := runSmokeTest(ctx, config); err != nil {
+		r.fail("GraphJin", err,
+			fmt.Sprintf("the user needs read access to the tables in the %s schema; run with --graphjin-debug for details", config.Database.Schema))
+		return
+	}
+	r.pass("GraphJin loaded the schema and ran a query (%dms)", time.Since(start).Milliseconds())
+}
+
+// checkQueries finds the query files to validate
+func (r *doctorReport) checkQueries() []string {
+	files, err := discoverQueryFiles(queriesDir)
+	if err != nil {
+		r.fail("Queries", err, "create the directory, pass -q with its path, or set queries_dir in the config's validate section")
+		return nil
+	}
+	if len(files) == 0 {
+		r.warn(fmt.Sprintf("No query files in %s", queriesDir), fmt.Sprintf("add %s files there", strings.Join(queryExtensions, " or ")))
+		return nil
+	}
+	r.pass("Found %d query file(s) in %s", len(files), queriesDir)
+	return files
+}
+
+// checkVariables loads the variables file of each query and checks it holds
+// a JSON object, which GraphJin would otherwise only reject when the query
+// runs
+func (r *doctorReport) checkVariables(files []string) {
+	loaded := 0
+	for _, qf := range files {
+		varsFile := findVariablesFile(qf)
+		if varsFile == "" {
+			continue
+		}
+		vars, _, err := loadVariables(validationCtx, qf)
+		if err == nil {
+			var object map[string]interface{}
+			err = json.Unmarshal(vars, &object)
+		}
+		if err != nil {
+			r.fail(varsFile, err, "variables files must hold a single JSON (or YAML) object")
+			continue
+		}
+		loaded++
+	}
+	if loaded > 0 {
+		r.pass("%d variables file(s) load", loaded)
+	}
+}
@@ -0,0 +1,24 @@
+package cmd
+
+import "graphql-validation-tool/internal/engine"
+
+// ResultError is a machine-readable form of a validation error
+type ResultError = engine.ResultError
+
+// countErrorCategories buckets the errors of failed results by category
+func countErrorCategories(results []TestResult) map[string]int {
+	counts := make(map[string]int)
+	for _, result := range results {
+		if result.Passed || result.Skipped {
+			continue
+		}
+		details := result.ErrorDetails
+		if details == nil {
+			details = engine.StructuredErrors(result.Errors)
+		}
+		for _, detail := range details {
+			counts[engine.ErrorCategoryLabel(detail.Code)]++
+		}
+	}
+	return counts
+}
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var explainPlan bool
+
+// ExplainResult represents the SQL GraphJin generates for a query
+type ExplainResult struct {
+	Name string   `json:"name"`
+	Path string   `json:"path"`
+	SQL  string   `json:"sql"`
+	Plan []string `json:"plan,omitempty"`
+}
+
+var explainCmd = &cobra.Command{
+	Use:   "explain",
+	Short: "Show the SQL GraphJin generates for a query",
+	Long: `Compile a GraphQL query and print the SQL GraphJin generates for it.
+
+With --plan the generated SQL is also run through EXPLAIN and the query
+plan is printed. Queries with variables are explained with a generic plan
+(EXPLAIN (GENERIC_PLAN), PostgreSQL 16+), since the SQL is parameterized.
+
+Examples:
+  # Show the generated SQL
+  gql-validate explain -f ./queries/get_user.graphql
+
+  # Also show the query plan
+  gql-validate explain -f ./queries/get_user.graphql --plan
+
+  # Output as JSON
+  gql-validate explain -f ./queries/get_user.graphql -j`,
+	RunE: runExplain,
+}
+
+func init() {
+	rootCmd.AddCommand(explainCmd)
+
+	explainCmd.Flags().StringVarP(&queryFile, "file", "f", "", "GraphQL file to explain")
+	explainCmd.Flags().BoolVar(&explainPlan, "plan", false, "run EXPLAIN on the generated SQL and show the plan")
+	explainCmd.MarkFlagRequired("file")
+}
+
+func runExplain(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	config, err := LoadConfig(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	query, err := readQueryFile(queryFile)
+	if err != nil {
+		return fmt.Errorf("failed to read query file: %w", err)
+	}
+
+	variables, _, err := loadVariables(validationCtx, queryFile)
+	if err != nil {
+		return fmt.Errorf("failed to load variables: %w", err)
+	}
+
+	// Initialize GraphJin
+	gj, db, err := initializeGraphJin(config)
+	if err != nil {
+		return fmt.Errorf("failed to initialize GraphJin: %w", err)
+	}
+	defer db.Close()
+
+	ctx := withQueryUser(context.Background(), "")
+	res, err := gj.GraphQL(ctx, query, variables, nil)
+	if err != nil {
+		return fmt.Errorf("failed to compile query: %w", err)
+	}
+
+	result := ExplainResult{
+		Name: filepath.Base(queryFile),
+		Path: queryFile,
+		SQL:  res.SQL(),
+	}
+
+	if result.SQL == "" {
+		return fmt.Errorf("GraphJin did not generate SQL for this query")
+	}
+
+	if explainPlan {
+		result.Plan, err = explainSQL(ctx, db, result.SQL)
+		if err != nil {
+			return fmt.Errorf("failed to explain query: %w", err)
+		}
+	}
+
+	if jsonOutput {
+		jsonData, err := marshalOutput(result)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(jsonData))
+		return nil
+	}
+
+	fmt.Println()
+	fmt.Printf("-- SQL for %s\n", result.Name)
+	fmt.Println(result.SQL)
+	if len(result.Plan) > 0 {
+		fmt.Println()
+		fmt.Println("-- Query plan")
+		for _, line := range result.Plan {
+			fmt.Println(line)
+		}
+	}
+	fmt.Println()
+
+	return nil
+}
+
+// explainSQL returns the EXPLAIN output for a statement, using a generic
+// plan when the statement has parameter placeholders
+func explainSQL(ctx context.Context, db *sql.DB, query string) ([]string, error) {
+	stmt := "EXPLAIN " + query
+	if strings.Contains(query, "$1") {
+		stmt = "EXPLAIN (GENERIC_PLAN) " + query
+	}
+
+	rows, err := db.QueryContext(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var plan []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, err
+		}
+		plan = append(plan, line)
+	}
+
+	return plan, rows.Err()
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+)
+
+// setupFile and teardownFile are SQL scripts run against each database
+// before and after its queries are validated
+var (
+	setupFile    string
+	teardownFile string
+)
+
+// runFixtures runs the --setup script against the target's database and
+// returns a function running the --teardown script. The teardown function
+// is returned even when setup fails, so a partially applied fixture is
+// still cleaned up.
+func runFixtures(config *Config) (teardown func(), err error) {
+	teardown = func() {}
+	if setupFile == "" && teardownFile == "" {
+		return teardown, nil
+	}
+
+	db, err := sql.Open("pgx", config.GetDSN())
+	if err != nil {
+		return teardown, fmt.Errorf("failed to connect to database: %w", redactError(err))
+	}
+
+	teardown = func() {
+		defer db.Close()
+		if teardownFile == "" {
+			return
+		}
+		if err := runSQLFile(db, teardownFile); err != nil {
+			logError("Teardown failed: %v", err)
+			return
+		}
+		logDebug("Ran teardown script: %s", teardownFile)
+	}
+
+	if setupFile != "" {
+		if err := runSQLFile(db, setupFile); err != nil {
+			return teardown, fmt.Errorf("setup failed: %w", err)
+		}
+		logDebug("Ran setup script: %s", setupFile)
+	}
+
+	return teardown, nil
+}
+
+// runSQLFile executes a SQL script, which may hold several statements
+func runSQLFile(db *sql.DB, path string) error {
+	script, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if _, err := db.Exec(string(script)); err != nil {
+		return fmt.Errorf("failed to run %s: %w", path, redactError(err))
+	}
+	return nil
+}
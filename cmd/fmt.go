@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"graphql-validation-tool/internal/engine"
+
+	"github.com/chirino/graphql/schema"
+	"github.com/spf13/cobra"
+)
+
+var (
+	fmtCheck bool
+	fmtWrite bool
+)
+
+var fmtCmd = &cobra.Command{
+	Use:   "fmt [files...]",
+	Short: "Format GraphQL query files",
+	Long: `Rewrite GraphQL query files in a canonical format, similar to gofmt.
+
+Each file is parsed and printed with two-space indentation, consistent
+argument and variable spacing, and its operations and fragments sorted by
+name. The comment lines at the top of a file are preserved, since list uses
+them as query descriptions. Files with comments inside the document are
+left untouched, as those comments cannot be kept in place.
+
+Without --check or --write the formatted source is printed to stdout.
+
+Examples:
+  # Show the formatted version of every query
+  gql-validate fmt
+
+  # Rewrite files in place
+  gql-validate fmt --write
+
+  # Fail if any file is not formatted (for CI)
+  gql-validate fmt --check
+
+  # Format specific files
+  gql-validate fmt --write ./queries/get_user.graphql`,
+	RunE: runFmt,
+}
+
+func init() {
+	rootCmd.AddCommand(fmtCmd)
+
+	fmtCmd.Flags().StringVarP(&queriesDir, "queries", "q", "./queries", "directory containing GraphQL query files")
+	fmtCmd.Flags().BoolVar(&fmtCheck, "check", false, "list files that are not formatted and exit non-zero if there are any")
+	fmtCmd.Flags().BoolVar(&fmtWrite, "write", false, "write the formatted source back to each file")
+	fmtCmd.MarkFlagsMutuallyExclusive("check", "write")
+}
+
+func runFmt(cmd *cobra.Command, args []string) error {
+	files := args
+	if len(files) == 0 {
+		// Fall back to the config file's queries settings, if present
+		config, err := LoadOptionalConfig(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		applyQueriesConfig(cmd, config)
+
+		files, err = discoverQueryFiles(queriesDir)
+		if err != nil {
+			return err
+		}
+		files = selectQueryFiles(files)
+	}
+
+	if len(files) == 0 {
+		logWarn("No query files found")
+		return nil
+	}
+
+	var unformatted, failed int
+	for _, path := range files {
+		content, err := engine.ReadQuerySource(path)
+		if err != nil {
+			logError("Failed to read %s: %v", path, err)
+			failed++
+			continue
+		}
+
+		formatted, err := formatQuery(content)
+		if err != nil {
+			logError("Failed to format %s: %v", path, err)
+			failed++
+			continue
+		}
+
+		changed := formatted != string(content)
+		switch {
+		case fmtCheck:
+			if changed {
+				fmt.Println(path)
+				unformatted++
+			}
+		case fmtWrite:
+			if !changed {
+				continue
+			}
+			if err := os.WriteFile(path, []byte(formatted), 0644); err != nil {
+				logError("Failed to write %s: %v", path, err)
+				failed++
+				continue
+			}
+			logInfo("Formatted %s", path)
+		default:
+			fmt.Print(formatted)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d file(s) could not be formatted", failed)
+	}
+	if unformatted > 0 {
+		return fmt.Errorf("%d file(s) are not formatted, run gql-validate fmt --write", unformatted)
+	}
+
+	return nil
+}
+
+// formatQuery returns the canonical form of a GraphQL document, keeping its
+// leading comment block
+func formatQuery(src string) (string, error) {
+	header, body := splitLeadingComments(src)
+
+	if hasComments(body) {
+		return "", fmt.Errorf("comments inside the document cannot be preserved")
+	}
+
+	doc, err := engine.ParseDocument(body)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if header != "" {
+		b.WriteString(header)
+		b.WriteString("\n\n")
+	}
+
+	operations := append([]*schema.Operation(nil), doc.Operations...)
+	sort.SliceStable(operations, func(i, j int) bool {
+		return operations[i].Name < operations[j].Name
+	})
+
+	fragments := append([]*schema.FragmentDecl(nil), doc.Fragments...)
+	sort.SliceStable(fragments, func(i, j int) bool {
+		return fragments[i].Name < fragments[j].Name
+	})
+
+	first := true
+	separate := func() {
+		if !first {
+			b.WriteString("\n")
+		}
+		first = false
+	}
+
+	for _, op := range operations {
+		separate()
+		engine.WriteOperation(&b, op)
+	}
+
+	for _, frag := range fragments {
+		separate()
+		engine.WriteFragment(&b, frag)
+	}
+
+	return b.String(), nil
+}
+
+// splitLeadingComments separates the comment lines at the top of a file
+// from the document that follows, trimming blank lines around the header
+func splitLeadingComments(src string) (string, string) {
+	lines := strings.Split(src, "\n")
+
+	var header []string
+	i := 0
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(line, "#") {
+			header = append(header, line)
+		} else if line != "" {
+			break
+		}
+	}
+
+	return strings.Join(header, "\n"), strings.Join(lines[i:], "\n")
+}
+
+// hasComments reports whether a document contains a comment outside of
+// string literals
+func hasComments(src string) bool {
+	for i := 0; i < len(src); i++ {
+		switch src[i] {
+		case '#':
+			return true
+		case '"':
+			if strings.HasPrefix(src[i:], `"""`) {
+				end := strings.Index(src[i+3:], `"""`)
+				if end < 0 {
+					return false
+				}
+				i += end + 5
+				continue
+			}
+			for i++; i < len(src) && src[i] != '"' && src[i] != '\n'; i++ {
+				if src[i] == '\\' {
+					i++
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,343 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"graphql-validation-tool/internal/engine"
+)
+
+// writeResultsCSV writes one row per validation result, preceded by a header row
+func writeResultsCSV(w io.Writer, summary ValidationSummary) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write([]string{"name", "path", "passed", "duration_ms", "errors"}); err != nil {
+		return err
+	}
+
+	for _, result := range summary.Results {
+		row := []string{
+			result.Name,
+			result.Path,
+			strconv.FormatBool(result.Passed),
+			strconv.FormatInt(result.Duration, 10),
+			strings.Join(result.Errors, "; "),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// streamMu serializes ndjson lines written as validations finish
+var streamMu sync.Mutex
+
+// streamResult writes a finished result as one ndjson line, when that is
+// the output format
+func streamResult(result TestResult) {
+	if outputFormat != "ndjson" {
+		return
+	}
+
+	line, err := json.Marshal(result)
+	if err != nil {
+		logError("Failed to write result: %v", err)
+		return
+	}
+
+	streamMu.Lock()
+	defer streamMu.Unlock()
+	fmt.Fprintln(resultsOutput, string(line))
+}
+
+// writeNDJSONSummary writes the final ndjson line: the summary under a
+// "summary" key, without the results already streamed
+func writeNDJSONSummary(w io.Writer, summary ValidationSummary) error {
+	summary.Results = nil
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	delete(fields, "results")
+
+	line, err := json.Marshal(map[string]interface{}{"summary": fields})
+	if err != nil {
+		return err
+	}
+
+	streamMu.Lock()
+	defer streamMu.Unlock()
+	_, err = fmt.Fprintln(w, string(line))
+	return err
+}
+
+// sarifSchema is the JSON schema URI of the SARIF version written
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// lineColumnPattern extracts the position reported in GraphQL parse errors
+var lineColumnPattern = regexp.MustCompile(`line (\d+), column (\d+)`)
+
+// errorPosition returns the line and column an error points at, taken from
+// its location or from a position mentioned in its message
+func errorPosition(detail ResultError) (int, int, bool) {
+	var m []string
+	if detail.Location != "" {
+		m = append([]string{""}, strings.SplitN(detail.Location, ":", 2)...)
+	} else {
+		m = lineColumnPattern.FindStringSubmatch(detail.Message)
+	}
+	if len(m) != 3 {
+		return 0, 0, false
+	}
+
+	line, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0, 0, false
+	}
+	column, err := strconv.Atoi(m[2])
+	if err != nil {
+		return 0, 0, false
+	}
+	return line, column, true
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name    string      `json:"name"`
+	Version string      `json:"version"`
+	Rules   []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// writeResultsSARIF writes a SARIF 2.1.0 log with one result per error of
+// each failing query, using the error code as the rule id
+func writeResultsSARIF(w io.Writer, summary ValidationSummary) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:    "gql-validate",
+			Version: Version,
+		}},
+		Results: []sarifResult{},
+	}
+
+	rules := make(map[string]bool)
+	for _, result := range summary.Results {
+		if result.Passed || result.Skipped {
+			continue
+		}
+
+		for _, detail := range engine.StructuredErrors(result.Errors) {
+			if !rules[detail.Code] {
+				rules[detail.Code] = true
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+					ID:               detail.Code,
+					ShortDescription: sarifMessage{Text: strings.ToLower(strings.ReplaceAll(detail.Code, "_", " "))},
+				})
+			}
+
+			message := detail.Message
+			if detail.Path != "" {
+				message = fmt.Sprintf("%s (at %s)", message, detail.Path)
+			}
+			if result.Target != "" {
+				message = fmt.Sprintf("[%s] %s", result.Target, message)
+			}
+
+			location := sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(result.Path)},
+			}
+			if line, column, ok := errorPosition(detail); ok {
+				location.Region = &sarifRegion{StartLine: line, StartColumn: column}
+			}
+
+			run.Results = append(run.Results, sarifResult{
+				RuleID:    detail.Code,
+				Level:     "error",
+				Message:   sarifMessage{Text: message},
+				Locations: []sarifLocation{{PhysicalLocation: location}},
+			})
+		}
+	}
+
+	sort.Slice(run.Tool.Driver.Rules, func(i, j int) bool {
+		return run.Tool.Driver.Rules[i].ID < run.Tool.Driver.Rules[j].ID
+	})
+	if run.Tool.Driver.Rules == nil {
+		run.Tool.Driver.Rules = []sarifRule{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Schema:  sarifSchema,
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
+
+// writeMetrics writes run totals and per-query durations in the Prometheus
+// text exposition format
+func writeMetrics(w io.Writer, summary ValidationSummary) error {
+	var b strings.Builder
+
+	gauge := func(name, help string, value int) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n%s %d\n", name, help, name, name, value)
+	}
+	gauge("gqlvalidate_total", "Number of queries validated.", summary.Total)
+	gauge("gqlvalidate_passed", "Number of queries that passed validation.", summary.Passed)
+	gauge("gqlvalidate_failed", "Number of queries that failed validation.", summary.Failed)
+	gauge("gqlvalidate_skipped", "Number of known-failing queries skipped.", summary.Skipped)
+
+	b.WriteString("# HELP gqlvalidate_query_duration_ms Validation time of each query in milliseconds.\n")
+	b.WriteString("# TYPE gqlvalidate_query_duration_ms gauge\n")
+	for _, result := range summary.Results {
+		labels := fmt.Sprintf(`query="%s"`, escapeLabelValue(result.Path))
+		if result.Target != "" {
+			labels += fmt.Sprintf(`,target="%s"`, escapeLabelValue(result.Target))
+		}
+		fmt.Fprintf(&b, "gqlvalidate_query_duration_ms{%s} %d\n", labels, result.Duration)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// QueryTiming is one query's entry in the --timings-out file
+type QueryTiming struct {
+	Name            string `json:"name"`
+	Path            string `json:"path"`
+	Target          string `json:"target,omitempty"`
+	Role            string `json:"role,omitempty"`
+	Duration        int64  `json:"duration_ms"`
+	CompileDuration int64  `json:"compile_duration_ms"`
+	ExecuteDuration int64  `json:"execute_duration_ms"`
+}
+
+// queryTimings lists the durations of the queries that ran, passed or
+// failed; skipped queries never ran
+func queryTimings(results []TestResult) []QueryTiming {
+	timings := []QueryTiming{}
+	for _, result := range results {
+		if result.Skipped {
+			continue
+		}
+		timings = append(timings, QueryTiming{
+			Name:            result.Name,
+			Path:            result.Path,
+			Target:          result.Target,
+			Role:            result.Role,
+			Duration:        result.Duration,
+			CompileDuration: result.CompileDuration,
+			ExecuteDuration: result.ExecuteDuration,
+		})
+	}
+	return timings
+}
+
+// writeTimingsJSON writes the query durations as a JSON array
+func writeTimingsJSON(w io.Writer, timings []QueryTiming) error {
+	data, err := marshalOutput(timings)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// writeTimingsCSV writes one row per query duration, preceded by a header row
+func writeTimingsCSV(w io.Writer, timings []QueryTiming) error {
+	writer := csv.NewWriter(w)
+
+	header := []string{"name", "path", "target", "role", "duration_ms", "compile_duration_ms", "execute_duration_ms"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, t := range timings {
+		row := []string{
+			t.Name,
+			t.Path,
+			t.Target,
+			t.Role,
+			strconv.FormatInt(t.Duration, 10),
+			strconv.FormatInt(t.CompileDuration, 10),
+			strconv.FormatInt(t.ExecuteDuration, 10),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// labelValueEscaper escapes Prometheus label values
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(v string) string {
+	return labelValueEscaper.Replace(v)
+}
@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"context"
+
+	"graphql-validation-tool/internal/engine"
+)
+
+// queryUser is who queries run as, from the graphjin section of the config
+var queryUser struct {
+	id   string
+	role string
+}
+
+// withQueryUser runs a query as role, or as the configured default role
+// when role is empty, and as the configured user
+func withQueryUser(ctx context.Context, role string) context.Context {
+	if role == "" {
+		role = queryUser.role
+	}
+	return engine.WithQueryUser(ctx, role, queryUser.id)
+}
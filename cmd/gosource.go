@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"graphql-validation-tool/internal/engine"
+)
+
+// scanGo validates queries embedded in Go source instead of query files
+var scanGo bool
+
+// goQueryMarker is the comment that marks the next string literal as a
+// GraphQL query
+const goQueryMarker = "gql"
+
+// loadGoQueries extracts the marked queries from the Go files under dirs,
+// returning their pseudo paths in file order along with the queries keyed by
+// those paths. Each query's path is "<file>:<line>".
+func loadGoQueries(dirs []string) ([]string, map[string]queryInput, error) {
+	goFiles, err := findGoFiles(dirs)
+	if err != nil {
+		return nil, nil, err
+	}
+	if changedOnly {
+		goFiles, err = selectChangedFiles(goFiles)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var paths []string
+	inputs := make(map[string]queryInput)
+	for _, path := range goFiles {
+		queries, err := extractGoQueries(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, input := range queries {
+			paths = append(paths, input.Path)
+			inputs[input.Path] = input
+		}
+	}
+	logDebug("Found %d marked query(s) in %d Go file(s)", len(paths), len(goFiles))
+
+	return paths, inputs, nil
+}
+
+// findGoFiles returns the Go files under dirs, leaving out vendored code
+func findGoFiles(dirs []string) ([]string, error) {
+	var goFiles []string
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+			if !allowMissing {
+				return nil, fmt.Errorf("source directory not found: %s (pass --allow-missing to allow this)", dir)
+			}
+			logWarn("Source directory not found: %s", dir)
+			continue
+		}
+
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() && info.Name() == "vendor" {
+				return filepath.SkipDir
+			}
+			if !info.IsDir() && strings.HasSuffix(info.Name(), ".go") {
+				goFiles = append(goFiles, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find Go files: %w", err)
+		}
+	}
+	return goFiles, nil
+}
+
+// extractGoQueries parses a Go file and returns the string literal following
+// each //gql marker comment, either on the marker's line or the next one:
+//
+//	//gql
+//	const getUserQuery = `query { users { id } }`
+func extractGoQueries(path string) ([]queryInput, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, nil, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Go file: %w", err)
+	}
+
+	// Lines holding a marker, mapped to where the marker ends
+	markers := make(map[int]token.Pos)
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			text := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(c.Text, "//"), "/*"), "*/"))
+			if text == goQueryMarker {
+				markers[fset.Position(c.End()).Line] = c.End()
+			}
+		}
+	}
+	if len(markers) == 0 {
+		return nil, nil
+	}
+
+	var queries []queryInput
+	ast.Inspect(file, func(n ast.Node) bool {
+		lit, ok := n.(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return true
+		}
+
+		line := fset.Position(lit.Pos()).Line
+		marker, ok := markers[line]
+		if !ok || marker > lit.Pos() {
+			if marker, ok = markers[line-1]; !ok {
+				return true
+			}
+			line--
+		}
+		// Only the first literal after a marker is a query
+		delete(markers, line)
+
+		query, err := strconv.Unquote(lit.Value)
+		if err != nil {
+			return true
+		}
+		// The query starts right after the literal's opening quote
+		pos := fset.Position(lit.Pos())
+		key := fmt.Sprintf("%s:%d", path, pos.Line)
+		queries = append(queries, queryInput{
+			Name:      filepath.Base(key),
+			Path:      key,
+			Query:     query,
+			Variables: json.RawMessage("{}"),
+			Source:    engine.EmbeddedSourceMap(path, query, pos.Line, pos.Column),
+		})
+		return true
+	})
+
+	return queries, nil
+}
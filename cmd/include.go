@@ -0,0 +1,25 @@
+package cmd
+
+import "graphql-validation-tool/internal/engine"
+
+// sourceMap maps the lines of an expanded query back to the files they
+// came from
+type sourceMap = engine.SourceMap
+
+// readQueryFile reads a query file with its includes resolved
+func readQueryFile(path string) (string, error) {
+	query, _, err := readMappedQueryFile(path)
+	return query, err
+}
+
+// readMappedQueryFile reads a query file with its includes resolved, along
+// with where each line of the result came from
+func readMappedQueryFile(path string) (string, sourceMap, error) {
+	return validationOptions().ReadQueryFile(path)
+}
+
+// excludePartialFiles drops files that other query files include, since
+// partials are selection blocks rather than standalone operations
+func excludePartialFiles(queryFiles []string) []string {
+	return validationOptions().ExcludePartialFiles(queryFiles)
+}
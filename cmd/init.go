@@ -11,8 +11,22 @@ import (
 var (
 	initDir   string
 	overwrite bool
+	initDB    string
 )
 
+// dbDefaults holds the database-specific values used in scaffolded files
+type dbDefaults struct {
+	Type    string
+	Name    string
+	Port    int
+	SSLMode string
+}
+
+var supportedDBTypes = map[string]dbDefaults{
+	"postgres": {Type: "postgres", Name: "PostgreSQL", Port: 5432, SSLMode: "disable"},
+	"mysql":    {Type: "mysql", Name: "MySQL", Port: 3306, SSLMode: "disabled"},
+}
+
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initialize a new GraphQL validation project",
@@ -28,6 +42,9 @@ Examples:
   # Initialize in a specific directory
   gql-validate init -d ./my-project
 
+  # Scaffold for a MySQL database
+  gql-validate init --db-type mysql
+
   # Overwrite existing files
   gql-validate init --overwrite`,
 	RunE: runInit,
@@ -38,39 +55,45 @@ func init() {
 
 	initCmd.Flags().StringVarP(&initDir, "dir", "d", ".", "directory to initialize the project in")
 	initCmd.Flags().BoolVar(&overwrite, "overwrite", false, "overwrite existing files")
+	initCmd.Flags().StringVar(&initDB, "db-type", "postgres", "database type to scaffold for: postgres or mysql")
 }
 
 func runInit(cmd *cobra.Command, args []string) error {
-	fmt.Printf("Initializing GraphQL validation project in: %s\n\n", initDir)
+	db, ok := supportedDBTypes[initDB]
+	if !ok {
+		return fmt.Errorf("unsupported database type %q (expected postgres or mysql)", initDB)
+	}
+
+	logInfo("Initializing GraphQL validation project in: %s\n", initDir)
 
 	// Create directories
 	queriesDir := filepath.Join(initDir, "queries")
 	if err := os.MkdirAll(queriesDir, 0755); err != nil {
 		return fmt.Errorf("failed to create queries directory: %w", err)
 	}
-	fmt.Printf("  ✓ Created directory: %s\n", queriesDir)
+	logInfo("  ✓ Created directory: %s", queriesDir)
 
 	// Create config.yaml
 	configPath := filepath.Join(initDir, "config.yaml")
-	if err := writeFileIfNotExists(configPath, sampleConfig, overwrite); err != nil {
+	if err := writeFileIfNotExists(configPath, fmt.Sprintf(sampleConfig, db.Name, db.Type, db.Port, db.SSLMode), overwrite); err != nil {
 		return err
 	}
 
 	// Create .env.example
 	envPath := filepath.Join(initDir, ".env.example")
-	if err := writeFileIfNotExists(envPath, sampleEnv, overwrite); err != nil {
+	if err := writeFileIfNotExists(envPath, fmt.Sprintf(sampleEnv, db.Name, db.Port, db.SSLMode), overwrite); err != nil {
 		return err
 	}
 
 	// Create sample query
 	queryPath := filepath.Join(queriesDir, "get_users.graphql")
-	if err := writeFileIfNotExists(queryPath, sampleQuery, overwrite); err != nil {
+	if err := writeFileIfNotExists(queryPath, fmt.Sprintf(sampleQuery, db.Name), overwrite); err != nil {
 		return err
 	}
 
 	// Create sample query with variables
 	queryWithVarsPath := filepath.Join(queriesDir, "get_user_by_id.graphql")
-	if err := writeFileIfNotExists(queryWithVarsPath, sampleQueryWithVars, overwrite); err != nil {
+	if err := writeFileIfNotExists(queryWithVarsPath, fmt.Sprintf(sampleQueryWithVars, db.Name), overwrite); err != nil {
 		return err
 	}
 
@@ -107,7 +130,7 @@ func runInit(cmd *cobra.Command, args []string) error {
 
 func writeFileIfNotExists(path, content string, overwrite bool) error {
 	if _, err := os.Stat(path); err == nil && !overwrite {
-		fmt.Printf("  ○ Skipped (exists): %s\n", path)
+		logInfo("  ○ Skipped (exists): %s", path)
 		return nil
 	}
 
@@ -115,41 +138,44 @@ func writeFileIfNotExists(path, content string, overwrite bool) error {
 		return fmt.Errorf("failed to write %s: %w", path, err)
 	}
 
-	fmt.Printf("  ✓ Created: %s\n", path)
+	logInfo("  ✓ Created: %s", path)
 	return nil
 }
 
-const sampleConfig = `# GraphQL Validation Tool Configuration
+const sampleConfig = `# GraphQL Validation Tool Configuration (%s)
 # Database credentials can be overridden with environment variables:
 # DB_HOST, DB_PORT, DB_NAME, DB_USER, DB_PASSWORD, DB_SSLMODE
 
 database:
-  type: "postgres"
+  type: "%s"
   host: "localhost"
-  port: 5432
+  port: %d
   dbname: "your_database"
   user: "your_user"
   password: "your_password"
-  sslmode: "disable"
+  sslmode: "%s"
 
 # Set to true for production mode (disables debug output)
 production: false
+
+# Set to true to print GraphJin's own debug logging
+graphjin_debug: false
 `
 
-const sampleEnv = `# Database Configuration
+const sampleEnv = `# Database Configuration (%s)
 # Copy this file to .env and fill in your values
 # Then run: source .env
 
 export DB_HOST=localhost
-export DB_PORT=5432
+export DB_PORT=%d
 export DB_NAME=your_database
 export DB_USER=your_user
 export DB_PASSWORD=your_password
-export DB_SSLMODE=disable
+export DB_SSLMODE=%s
 `
 
 const sampleQuery = `# Sample query to fetch all users
-# Modify this to match your database schema
+# Modify this to match your %s database schema
 
 query GetUsers {
   users {
@@ -163,6 +189,7 @@ query GetUsers {
 
 const sampleQueryWithVars = `# Sample query with variables
 # Variables are provided in the corresponding .json file
+# Modify this to match your %s database schema
 
 query GetUserById($id: Int!) {
   users(where: { id: { eq: $id } }) {
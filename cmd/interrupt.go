@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+)
+
+// interruptedExitCode is the exit status of a run stopped by SIGINT or
+// SIGTERM, following the shell's 128 + SIGINT convention
+const interruptedExitCode = 130
+
+var (
+	// validationCtx is cancelled when the run is interrupted, aborting the
+	// queries in flight
+	validationCtx = context.Background()
+
+	wasInterrupted atomic.Bool
+)
+
+// handleInterrupts cancels validationCtx on the first SIGINT or SIGTERM so
+// the run can stop and report what it validated so far. A second signal
+// terminates the process as usual.
+func handleInterrupts() (stop func()) {
+	ctx, cancel := context.WithCancel(validationCtx)
+	validationCtx = ctx
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-signals:
+			signal.Stop(signals)
+			wasInterrupted.Store(true)
+			logWarn("Interrupted, reporting the queries validated so far (interrupt again to quit)")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
+
+// interrupted reports whether the run was stopped by a signal
+func interrupted() bool {
+	return wasInterrupted.Load()
+}
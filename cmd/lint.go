@@ -0,0 +1,266 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"graphql-validation-tool/internal/engine"
+
+	"github.com/chirino/graphql/schema"
+)
+
+// Lint issue kinds reported by list --lint
+const (
+	lintOrphanedVariables = "orphaned_variables"
+	lintMissingVariables  = "missing_variables"
+	lintMissingDesc       = "missing_description"
+	lintUnknownDirective  = "unknown_directive"
+	lintDuplicateOp       = "duplicate_operation"
+	lintMissingLimit      = "missing_limit"
+)
+
+// requireDescription reports query files without a description comment
+var requireDescription bool
+
+// LintIssue is a problem found in the queries tree
+type LintIssue struct {
+	Kind    string `json:"kind"`
+	Path    string `json:"path"`
+	Message string `json:"message"`
+	// Warning marks issues that only fail the lint with --strict
+	Warning bool `json:"warning,omitempty"`
+}
+
+// lintQueriesTree reports variables files without a matching query file,
+// query files whose required variables are missing from their variables
+// file, unknown header directives, duplicate operation names, unbounded
+// collection selections and, with --require-description, query files
+// without a description
+func lintQueriesTree(dir string) ([]LintIssue, error) {
+	queryBases := make(map[string]bool)
+	var queryFiles, varsFiles []string
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		switch {
+		case isQueryFile(info.Name()):
+			queryBases[queryBasePath(path)] = true
+			if queryFileSelected(path) {
+				queryFiles = append(queryFiles, path)
+			}
+		case strings.HasSuffix(info.Name(), ".json"), strings.HasSuffix(info.Name(), engine.VariablesYAMLSuffix):
+			varsFiles = append(varsFiles, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []LintIssue
+
+	for _, vf := range varsFiles {
+		base := strings.TrimSuffix(strings.TrimSuffix(strings.TrimSuffix(vf, engine.VariablesYAMLSuffix), ".json"), ".schema")
+		// foo.<env>.json is an --env override of foo's variables
+		envBase := strings.TrimSuffix(base, filepath.Ext(base))
+		if !queryBases[base] && !queryBases[envBase] {
+			issues = append(issues, LintIssue{
+				Kind:    lintOrphanedVariables,
+				Path:    vf,
+				Message: "no matching query file",
+			})
+		}
+	}
+
+	// operationPaths maps named operations to the files defining them;
+	// anonymous operations are identified by their file, so never clash
+	operationPaths := make(map[string][]string)
+
+	for _, qf := range queryFiles {
+		if content, err := engine.ReadQuerySource(qf); err == nil {
+			if doc, err := engine.ParseDocument(content); err == nil {
+				issues = append(issues, missingLimitIssues(qf, doc)...)
+				for _, op := range doc.Operations {
+					if op.Name != "" {
+						operationPaths[op.Name] = append(operationPaths[op.Name], qf)
+					} else if len(doc.Operations) > 1 {
+						issues = append(issues, LintIssue{
+							Kind:    lintDuplicateOp,
+							Path:    qf,
+							Message: fmt.Sprintf("%s operation must be the only operation in the document", engine.AnonymousOperation),
+						})
+					}
+				}
+			}
+
+			header := engine.ParseHeader(string(content))
+			if requireDescription && header.Description == "" {
+				issues = append(issues, LintIssue{
+					Kind:    lintMissingDesc,
+					Path:    qf,
+					Message: "no leading description comment",
+				})
+			}
+			for _, key := range header.Unknown {
+				issues = append(issues, LintIssue{
+					Kind:    lintUnknownDirective,
+					Path:    qf,
+					Message: fmt.Sprintf("unknown header directive %q", key),
+				})
+			}
+		}
+
+		missing, err := missingVariables(qf)
+		if err != nil {
+			logWarn("Skipping %s: %v", qf, err)
+			continue
+		}
+		if len(missing) > 0 {
+			issues = append(issues, LintIssue{
+				Kind:    lintMissingVariables,
+				Path:    qf,
+				Message: fmt.Sprintf("required variable(s) not in variables file: %s", strings.Join(missing, ", ")),
+			})
+		}
+	}
+
+	for name, paths := range operationPaths {
+		if len(paths) < 2 {
+			continue
+		}
+		for _, path := range paths {
+			issues = append(issues, LintIssue{
+				Kind:    lintDuplicateOp,
+				Path:    path,
+				Message: fmt.Sprintf("operation %q is defined %d times (%s)", name, len(paths), strings.Join(paths, ", ")),
+			})
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Path < issues[j].Path
+	})
+	return issues, nil
+}
+
+// missingLimitIssues warns about the top-level collections a document's
+// queries select without a limit or first argument, which return every row.
+// Selections by id and GraphJin's singular ById fields return one row.
+func missingLimitIssues(path string, doc *schema.QueryDocument) []LintIssue {
+	var issues []LintIssue
+	for _, op := range doc.Operations {
+		if op.Type != schema.Query {
+			continue
+		}
+		for _, field := range engine.SelectionFields(doc, op.Selections) {
+			if len(field.Selections) == 0 || strings.HasPrefix(field.Name, "__") || strings.HasSuffix(field.Name, "ById") {
+				continue
+			}
+			if _, ok := field.Arguments.Get("id"); ok {
+				continue
+			}
+			_, hasLimit := field.Arguments.Get("limit")
+			_, hasFirst := field.Arguments.Get("first")
+			if hasLimit || hasFirst {
+				continue
+			}
+			issues = append(issues, LintIssue{
+				Kind:    lintMissingLimit,
+				Path:    path,
+				Message: fmt.Sprintf("%s selects %s without a limit or first argument, so it can return every row", engine.DisplayOperationName(op.Name), field.Alias),
+				Warning: !strictWarnings,
+			})
+		}
+	}
+	return issues
+}
+
+// missingVariables returns the non-null variables without defaults that a
+// query declares but its variables file does not provide. With variables
+// keyed by operation name, each operation is checked against its own and
+// reported as Op.$name.
+func missingVariables(queryPath string) ([]string, error) {
+	content, err := engine.ReadQuerySource(queryPath)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := engine.ParseDocument(content)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, _, err := loadVariables(validationCtx, queryPath)
+	if err != nil {
+		return nil, err
+	}
+	var provided map[string]interface{}
+	if err := json.Unmarshal(raw, &provided); err != nil {
+		return nil, fmt.Errorf("variables file is not a JSON object: %w", err)
+	}
+	perOp, _, keyed := engine.SplitOperationVariables(content, raw)
+
+	var missing []string
+	for _, op := range doc.Operations {
+		prefix := ""
+		if keyed {
+			prefix = op.Name + "."
+			provided = nil
+			_ = json.Unmarshal(perOp[op.Name], &provided)
+		}
+		for _, v := range op.Vars {
+			name := strings.TrimPrefix(v.Name, "$")
+			if _, required := v.Type.(*schema.NonNull); !required || v.Default != nil {
+				continue
+			}
+			if _, ok := provided[name]; !ok {
+				missing = append(missing, prefix+"$"+name)
+			}
+		}
+	}
+	return missing, nil
+}
+
+func printLintIssues(issues []LintIssue) error {
+	if jsonOutput {
+		jsonData, err := marshalOutput(map[string]interface{}{
+			"directory": queriesDir,
+			"issues":    issues,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(resultsOutput, string(jsonData))
+	} else if len(issues) == 0 {
+		fmt.Fprintln(resultsOutput, green(fmt.Sprintf("✓ No issues found in %s", queriesDir)))
+	} else {
+		for _, issue := range issues {
+			if issue.Warning {
+				fmt.Fprintf(resultsOutput, "%s: warning: %s\n", issue.Path, issue.Message)
+			} else {
+				fmt.Fprintf(resultsOutput, "%s: %s\n", issue.Path, issue.Message)
+			}
+		}
+	}
+
+	failing := 0
+	for _, issue := range issues {
+		if !issue.Warning {
+			failing++
+		}
+	}
+	if failing > 0 {
+		return fmt.Errorf("%d issue(s) found", failing)
+	}
+	return nil
+}
@@ -1,17 +1,24 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
+	"graphql-validation-tool/internal/engine"
+
 	"github.com/spf13/cobra"
 )
 
 var (
 	showFullPath bool
+	listSort     string
+	listFilter   string
+	withVars     bool
+	withoutVars  bool
+	listLint     bool
 )
 
 // QueryInfo represents information about a query file
@@ -22,6 +29,11 @@ type QueryInfo struct {
 	VarsFile    string `json:"variables_file,omitempty"`
 	SizeBytes   int64  `json:"size_bytes"`
 	Description string `json:"description,omitempty"`
+
+	// Operation and OperationName describe the file's first operation;
+	// unnamed operations are "(anonymous)"
+	Operation     string `json:"operation,omitempty"`
+	OperationName string `json:"operation_name,omitempty"`
 }
 
 var listCmd = &cobra.Command{
@@ -43,6 +55,18 @@ Examples:
   # Show full file paths
   gql-validate list --full-path
 
+  # Sort by file size, largest first
+  gql-validate list --sort size
+
+  # Only show queries whose name contains "user" and that have variables
+  gql-validate list --filter user --with-vars
+
+  # Report orphaned variables files and missing required variables
+  gql-validate list --lint
+
+  # Also fail on collections selected without a limit
+  gql-validate list --lint --strict
+
   # Output as JSON
   gql-validate list -j`,
 	RunE: runList,
@@ -53,81 +77,132 @@ func init() {
 
 	listCmd.Flags().StringVarP(&queriesDir, "queries", "q", "./queries", "directory containing GraphQL query files")
 	listCmd.Flags().BoolVar(&showFullPath, "full-path", false, "show full file paths")
+	listCmd.Flags().StringVar(&listSort, "sort", "path", "sort order: name, size or path")
+	listCmd.Flags().StringVar(&listFilter, "filter", "", "only show queries whose name contains this substring")
+	listCmd.Flags().BoolVar(&withVars, "with-vars", false, "only show queries that have a variables file")
+	listCmd.Flags().BoolVar(&withoutVars, "without-vars", false, "only show queries without a variables file")
+	listCmd.Flags().StringVarP(&outFile, "out", "o", "", "write the listing to this file instead of stdout")
+	listCmd.Flags().BoolVar(&listLint, "lint", false, "report orphaned variables files, required variables missing from them, duplicate operation names and unbounded collections")
+	listCmd.Flags().BoolVar(&requireDescription, "require-description", false, "with --lint, also report query files without a description comment")
+	listCmd.Flags().BoolVar(&strictWarnings, "strict", false, "with --lint, fail on warnings such as collections selected without a limit")
+	listCmd.MarkFlagsMutuallyExclusive("with-vars", "without-vars")
 }
 
 func runList(cmd *cobra.Command, args []string) error {
-	// Check if directory exists
-	if _, err := os.Stat(queriesDir); os.IsNotExist(err) {
-		return fmt.Errorf("queries directory not found: %s", queriesDir)
+	switch listSort {
+	case "name", "size", "path":
+	default:
+		return fmt.Errorf("invalid sort order %q (expected name, size or path)", listSort)
 	}
 
-	// Find all query files
-	var queries []QueryInfo
+	// Fall back to the config file's queries settings, if present
+	config, err := LoadOptionalConfig(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	applyQueriesConfig(cmd, config)
+
+	files, err := discoverQueryFiles(queriesDir)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		logWarn("No GraphQL query files found in: %s", queriesDir)
+		return nil
+	}
 
-	err := filepath.Walk(queriesDir, func(path string, info os.FileInfo, err error) error {
+	if listLint {
+		issues, err := lintQueriesTree(queriesDir)
 		if err != nil {
-			return err
+			return fmt.Errorf("failed to scan directory: %w", err)
 		}
+		return withResultsOutput(func() error {
+			return printLintIssues(issues)
+		})
+	}
 
-		if !info.IsDir() && strings.HasSuffix(info.Name(), ".graphql") {
-			query := QueryInfo{
-				Name:      info.Name(),
-				Path:      path,
-				SizeBytes: info.Size(),
-			}
-
-			// Check for corresponding JSON file
-			jsonFile := strings.TrimSuffix(path, ".graphql") + ".json"
-			if _, err := os.Stat(jsonFile); err == nil {
-				query.HasVars = true
-				query.VarsFile = jsonFile
-			}
+	// Describe each query file
+	var queries []QueryInfo
+	for _, path := range files {
+		if !queryFileSelected(path) {
+			continue
+		}
 
-			// Try to extract description from first comment line
-			if content, err := os.ReadFile(path); err == nil {
-				query.Description = extractDescription(string(content))
-			}
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed to scan directory: %w", err)
+		}
+		query := QueryInfo{
+			Name:      info.Name(),
+			Path:      path,
+			SizeBytes: info.Size(),
+		}
 
-			queries = append(queries, query)
+		// Check for a corresponding variables file
+		if varsFile := findVariablesFile(path); varsFile != "" {
+			query.HasVars = true
+			query.VarsFile = varsFile
 		}
 
-		return nil
-	})
+		// Try to extract description from first comment line
+		if content, err := engine.ReadQuerySource(path); err == nil {
+			query.Description = engine.ParseHeader(content).Description
+			query.Operation, query.OperationName, _ = engine.DescribeOperation(content)
+		}
 
-	if err != nil {
-		return fmt.Errorf("failed to scan directory: %w", err)
+		queries = append(queries, query)
 	}
 
+	queries = filterQueries(queries)
+	sortQueries(queries)
+
 	if len(queries) == 0 {
-		fmt.Printf("No GraphQL query files found in: %s\n", queriesDir)
+		logWarn("No GraphQL query files found in: %s", queriesDir)
 		return nil
 	}
 
 	// Output results
-	if jsonOutput {
-		return printListJSON(queries)
+	return withResultsOutput(func() error {
+		if jsonOutput {
+			return printListJSON(queries)
+		}
+		return printListText(queries)
+	})
+}
+
+// filterQueries applies the --filter, --with-vars and --without-vars flags
+func filterQueries(queries []QueryInfo) []QueryInfo {
+	filter := strings.ToLower(listFilter)
+
+	filtered := queries[:0]
+	for _, q := range queries {
+		if filter != "" && !strings.Contains(strings.ToLower(q.Name), filter) {
+			continue
+		}
+		if withVars && !q.HasVars {
+			continue
+		}
+		if withoutVars && q.HasVars {
+			continue
+		}
+		filtered = append(filtered, q)
 	}
 
-	return printListText(queries)
+	return filtered
 }
 
-func extractDescription(content string) string {
-	lines := strings.Split(content, "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "#") {
-			// Remove the # and leading space
-			desc := strings.TrimPrefix(line, "#")
-			desc = strings.TrimSpace(desc)
-			if desc != "" {
-				return desc
-			}
-		} else if line != "" {
-			// Stop at first non-comment, non-empty line
-			break
+// sortQueries orders queries according to the --sort flag
+func sortQueries(queries []QueryInfo) {
+	sort.SliceStable(queries, func(i, j int) bool {
+		switch listSort {
+		case "name":
+			return queries[i].Name < queries[j].Name
+		case "size":
+			return queries[i].SizeBytes > queries[j].SizeBytes
+		default:
+			return queries[i].Path < queries[j].Path
 		}
-	}
-	return ""
+	})
 }
 
 func printListJSON(queries []QueryInfo) error {
@@ -137,20 +212,20 @@ func printListJSON(queries []QueryInfo) error {
 		"queries":     queries,
 	}
 
-	jsonData, err := json.MarshalIndent(output, "", "  ")
+	jsonData, err := marshalOutput(output)
 	if err != nil {
 		return err
 	}
 
-	fmt.Println(string(jsonData))
+	fmt.Fprintln(resultsOutput, string(jsonData))
 	return nil
 }
 
 func printListText(queries []QueryInfo) error {
-	fmt.Println()
-	fmt.Printf("GraphQL Queries in: %s\n", queriesDir)
-	fmt.Println("═══════════════════════════════════════════════════════════════")
-	fmt.Println()
+	fmt.Fprintln(resultsOutput)
+	fmt.Fprintf(resultsOutput, "GraphQL Queries in: %s\n", queriesDir)
+	fmt.Fprintln(resultsOutput, "═══════════════════════════════════════════════════════════════")
+	fmt.Fprintln(resultsOutput)
 
 	for i, q := range queries {
 		displayPath := q.Name
@@ -158,10 +233,14 @@ func printListText(queries []QueryInfo) error {
 			displayPath = q.Path
 		}
 
-		fmt.Printf("  %d. %s\n", i+1, displayPath)
+		fmt.Fprintf(resultsOutput, "  %d. %s\n", i+1, displayPath)
 
 		if q.Description != "" {
-			fmt.Printf("     │ %s\n", q.Description)
+			fmt.Fprintf(resultsOutput, "     │ %s\n", q.Description)
+		}
+
+		if q.Operation != "" {
+			fmt.Fprintf(resultsOutput, "     └─ Operation: %s %s\n", q.Operation, q.OperationName)
 		}
 
 		if q.HasVars {
@@ -169,17 +248,17 @@ func printListText(queries []QueryInfo) error {
 			if showFullPath {
 				varsDisplay = q.VarsFile
 			}
-			fmt.Printf("     └─ Variables: %s\n", varsDisplay)
+			fmt.Fprintf(resultsOutput, "     └─ Variables: %s\n", varsDisplay)
 		}
 
 		if verbose {
-			fmt.Printf("     └─ Size: %d bytes\n", q.SizeBytes)
+			fmt.Fprintf(resultsOutput, "     └─ Size: %d bytes\n", q.SizeBytes)
 		}
 
-		fmt.Println()
+		fmt.Fprintln(resultsOutput)
 	}
 
-	fmt.Printf("Total: %d query file(s)\n", len(queries))
+	fmt.Fprintf(resultsOutput, "Total: %d query file(s)\n", len(queries))
 
 	// Count files with variables
 	withVars := 0
@@ -189,9 +268,9 @@ func printListText(queries []QueryInfo) error {
 		}
 	}
 	if withVars > 0 {
-		fmt.Printf("       %d with variables file(s)\n", withVars)
+		fmt.Fprintf(resultsOutput, "       %d with variables file(s)\n", withVars)
 	}
 
-	fmt.Println()
+	fmt.Fprintln(resultsOutput)
 	return nil
 }
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// logLevel controls which diagnostic messages are emitted
+type logLevel int
+
+const (
+	levelDebug logLevel = iota
+	levelInfo
+	levelWarn
+	levelError
+)
+
+var logLevelNames = map[string]logLevel{
+	"debug": levelDebug,
+	"info":  levelInfo,
+	"warn":  levelWarn,
+	"error": levelError,
+}
+
+var (
+	// activeLogLevel is resolved from --log-level and --verbose before each command runs
+	activeLogLevel = levelInfo
+
+	// logOutput receives all diagnostics; results are written to stdout separately
+	logOutput io.Writer = os.Stderr
+)
+
+// setupLogging resolves the active log level from the global flags.
+// --verbose lowers the level to debug regardless of --log-level.
+func setupLogging() error {
+	level, ok := logLevelNames[strings.ToLower(logLevelName)]
+	if !ok {
+		return fmt.Errorf("invalid log level %q (expected debug, info, warn or error)", logLevelName)
+	}
+
+	if verbose {
+		level = levelDebug
+	}
+
+	activeLogLevel = level
+	return nil
+}
+
+func logf(level logLevel, format string, args ...interface{}) {
+	if level < activeLogLevel {
+		return
+	}
+
+	msg := redactSecrets(fmt.Sprintf(format, args...))
+	if !strings.HasSuffix(msg, "\n") {
+		msg += "\n"
+	}
+	fmt.Fprint(logOutput, msg)
+}
+
+func logDebug(format string, args ...interface{}) {
+	logf(levelDebug, format, args...)
+}
+
+func logInfo(format string, args ...interface{}) {
+	logf(levelInfo, format, args...)
+}
+
+func logWarn(format string, args ...interface{}) {
+	logf(levelWarn, format, args...)
+}
+
+func logError(format string, args ...interface{}) {
+	logf(levelError, format, args...)
+}
+
+// cliLogger passes the engine's messages on to the log
+type cliLogger struct{}
+
+func (cliLogger) Debugf(format string, args ...interface{}) { logDebug(format, args...) }
+func (cliLogger) Warnf(format string, args ...interface{})  { logWarn(format, args...) }
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"graphql-validation-tool/internal/engine"
+
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	manifestFile string
+	// inlineQueries holds the queries loaded via --manifest, --scan-go,
+	// --scan-markdown or --scenario, keyed by the pseudo path used to
+	// identify them in results
+	inlineQueries map[string]queryInput
+)
+
+// Manifest is a YAML file listing named queries with inline bodies
+type Manifest struct {
+	Queries []ManifestEntry `yaml:"queries"`
+}
+
+// ManifestEntry is a single query defined in a manifest
+type ManifestEntry struct {
+	Name      string                 `yaml:"name"`
+	Query     string                 `yaml:"query"`
+	Variables map[string]interface{} `yaml:"variables"`
+	Role      string                 `yaml:"role"`
+	Operation string                 `yaml:"operation"`
+}
+
+// loadManifest reads a query manifest, returning the pseudo paths of its
+// queries in file order along with the queries keyed by those paths. Each
+// query's path is "<manifest>#<name>".
+func loadManifest(path string) ([]string, map[string]queryInput, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var manifest Manifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	paths := make([]string, 0, len(manifest.Queries))
+	inputs := make(map[string]queryInput, len(manifest.Queries))
+	for i, entry := range manifest.Queries {
+		if entry.Name == "" {
+			return nil, nil, fmt.Errorf("queries[%d]: name is required", i)
+		}
+		if entry.Query == "" {
+			return nil, nil, fmt.Errorf("query %s: query is required", entry.Name)
+		}
+
+		queryPath := path + "#" + entry.Name
+		if _, ok := inputs[queryPath]; ok {
+			return nil, nil, fmt.Errorf("query %s: duplicate name", entry.Name)
+		}
+
+		variables, err := manifestVariables(validationCtx, queryPath, entry.Variables)
+		if err != nil {
+			return nil, nil, fmt.Errorf("query %s: %w", entry.Name, err)
+		}
+
+		paths = append(paths, queryPath)
+		inputs[queryPath] = queryInput{
+			Name:      entry.Name,
+			Path:      queryPath,
+			Query:     entry.Query,
+			Variables: variables,
+			Role:      entry.Role,
+			Operation: entry.Operation,
+		}
+	}
+
+	return paths, inputs, nil
+}
+
+// manifestVariables converts the YAML variables of the query at key to
+// JSON, expanding environment references and template functions as in
+// variables files
+func manifestVariables(ctx context.Context, key string, vars map[string]interface{}) (json.RawMessage, error) {
+	if len(vars) == 0 {
+		return json.RawMessage("{}"), nil
+	}
+
+	data, err := json.Marshal(engine.JSONCompatible(vars))
+	if err != nil {
+		return nil, fmt.Errorf("invalid variables: %w", err)
+	}
+
+	data, err = engine.ExpandVariables(ctx, key, data)
+	if err != nil {
+		return nil, fmt.Errorf("could not expand variables: %w", err)
+	}
+	data, err = engine.SubstituteEnvJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("could not expand variables: %w", err)
+	}
+
+	return json.RawMessage(data), nil
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"graphql-validation-tool/internal/engine"
+)
+
+// scanMarkdown validates the GraphQL code blocks of Markdown files instead of
+// query files
+var scanMarkdown bool
+
+// loadMarkdownQueries extracts the graphql code blocks from the Markdown
+// files under dirs, returning their pseudo paths in file order along with the
+// queries keyed by those paths. Each query's path is "<file>#block-<n>".
+func loadMarkdownQueries(dirs []string) ([]string, map[string]queryInput, error) {
+	mdFiles, err := findMarkdownFiles(dirs)
+	if err != nil {
+		return nil, nil, err
+	}
+	if changedOnly {
+		mdFiles, err = selectChangedFiles(mdFiles)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var paths []string
+	inputs := make(map[string]queryInput)
+	for _, path := range mdFiles {
+		queries, err := extractMarkdownQueries(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, input := range queries {
+			paths = append(paths, input.Path)
+			inputs[input.Path] = input
+		}
+	}
+	logDebug("Found %d graphql block(s) in %d Markdown file(s)", len(paths), len(mdFiles))
+
+	return paths, inputs, nil
+}
+
+// findMarkdownFiles returns the Markdown files under dirs, leaving out
+// vendored code and installed packages
+func findMarkdownFiles(dirs []string) ([]string, error) {
+	var mdFiles []string
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+			if !allowMissing {
+				return nil, fmt.Errorf("docs directory not found: %s (pass --allow-missing to allow this)", dir)
+			}
+			logWarn("Docs directory not found: %s", dir)
+			continue
+		}
+
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() && (info.Name() == "vendor" || info.Name() == "node_modules") {
+				return filepath.SkipDir
+			}
+			if !info.IsDir() && strings.EqualFold(filepath.Ext(info.Name()), ".md") {
+				mdFiles = append(mdFiles, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find Markdown files: %w", err)
+		}
+	}
+	return mdFiles, nil
+}
+
+// extractMarkdownQueries returns the body of each fenced code block tagged
+// graphql in a Markdown file, numbered from 1 in document order:
+//
+//	```graphql
+//	query { users { id } }
+//	```
+func extractMarkdownQueries(path string) ([]queryInput, error) {
+	content, err := engine.ReadQuerySource(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Markdown file %s: %w", path, err)
+	}
+
+	var queries []queryInput
+	var fence string
+	var body []string
+	// start is the file line of the open block's first body line
+	start := 0
+	inQuery := false
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+
+		if fence == "" {
+			marker, info, ok := openingFence(line)
+			if !ok {
+				continue
+			}
+			// The language is the first word of the info string
+			language, _, _ := strings.Cut(info, " ")
+			fence = marker
+			inQuery = strings.EqualFold(language, "graphql")
+			body = nil
+			start = i + 2
+			continue
+		}
+
+		// A closing fence is at least as long as the opening one
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			if inQuery {
+				key := fmt.Sprintf("%s#block-%d", path, len(queries)+1)
+				query := strings.Join(body, "\n")
+				queries = append(queries, queryInput{
+					Name:      filepath.Base(key),
+					Path:      key,
+					Query:     query,
+					Variables: json.RawMessage("{}"),
+					Source:    engine.EmbeddedSourceMap(path, query, start, 0),
+				})
+			}
+			fence = ""
+			continue
+		}
+		if inQuery {
+			body = append(body, line)
+		}
+	}
+
+	return queries, nil
+}
+
+// openingFence reports whether line opens a fenced code block, returning its
+// run of backticks or tildes and the info string after it
+func openingFence(line string) (marker, info string, ok bool) {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 {
+		return "", "", false
+	}
+	for _, c := range []string{"`", "~"} {
+		n := len(trimmed) - len(strings.TrimLeft(trimmed, c))
+		if n >= 3 {
+			info = strings.TrimSpace(trimmed[n:])
+			// Backtick fences cannot have backticks in their info string
+			if c == "`" && strings.Contains(info, "`") {
+				return "", "", false
+			}
+			return trimmed[:n], info, true
+		}
+	}
+	return "", "", false
+}
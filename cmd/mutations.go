@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// mutationsDir is the directory, relative to a queries directory, that
+// holds the query files allowed to write
+var mutationsDir = "mutations"
+
+// rollbackWrites runs every query in a transaction that is rolled back
+var rollbackWrites bool
+
+// misplacedMutationError explains why a mutation outside the mutations
+// directory was rejected
+const misplacedMutationError = "Mutation not allowed outside %s/: this project keeps writes in that directory, where they run in rolled-back transactions"
+
+// findMutationsDirs returns the mutations directories present under the
+// queries directories. Having one opts the project into keeping mutations
+// there.
+func findMutationsDirs() []string {
+	var dirs []string
+	for _, root := range queriesDirs {
+		dir := filepath.Join(root, mutationsDir)
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// inMutationsDir reports whether a query file lies under one of dirs
+func inMutationsDir(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		rel, err := filepath.Rel(dir, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// enforceMutationsDir rejects the mutations outside the mutations
+// directories, returning the remaining files and a summary of the rejected
+// ones, and makes the run roll back everything it writes
+func enforceMutationsDir(queryFiles []string, dirs []string) ([]string, ValidationSummary) {
+	rollbackWrites = true
+	logDebug("Running queries in rolled-back transactions, mutations are only allowed in: %s", strings.Join(dirs, ", "))
+
+	misplaced := fmt.Sprintf(misplacedMutationError, mutationsDir)
+	return rejectMutationsWhere(queryFiles, misplaced, func(path string) bool {
+		return !inMutationsDir(path, dirs)
+	})
+}
+
+// openRollbackDB opens a connection pool whose connections are always
+// inside a transaction: one is begun on connect, then rolled back and begun
+// again whenever the connection is reused, so nothing is ever committed
+func openRollbackDB(dsn string) (*sql.DB, error) {
+	connConfig, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	begin := func(ctx context.Context, conn *pgx.Conn) error {
+		_, err := conn.Exec(ctx, "BEGIN")
+		return err
+	}
+	rollback := func(ctx context.Context, conn *pgx.Conn) error {
+		// A connection that cannot roll back must not be used again
+		if _, err := conn.Exec(ctx, "ROLLBACK"); err != nil {
+			return driver.ErrBadConn
+		}
+		return begin(ctx, conn)
+	}
+
+	return stdlib.OpenDB(*connConfig, stdlib.OptionAfterConnect(begin), stdlib.OptionResetSession(rollback)), nil
+}
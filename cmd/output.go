@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+var (
+	// outFile is the --out path results are written to instead of stdout
+	outFile string
+
+	// resultsOutput receives command results; diagnostics go to logOutput
+	resultsOutput io.Writer = os.Stdout
+
+	// compactJSON and jsonIndent control how JSON output is formatted
+	compactJSON bool
+	jsonIndent  = 2
+)
+
+// marshalOutput encodes v for JSON output, indented by --indent spaces or
+// on a single line with --compact
+func marshalOutput(v interface{}) ([]byte, error) {
+	if compactJSON || jsonIndent <= 0 {
+		return json.Marshal(v)
+	}
+	return json.MarshalIndent(v, "", strings.Repeat(" ", jsonIndent))
+}
+
+// withResultsOutput runs write with resultsOutput pointed at the --out
+// file, if one was given, restoring stdout afterwards
+func withResultsOutput(write func() error) error {
+	if outFile == "" {
+		return write()
+	}
+
+	f, err := os.Create(outFile)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	resultsOutput = f
+	defer func() { resultsOutput = os.Stdout }()
+
+	writeErr := write()
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	logDebug("Results written to: %s", outFile)
+
+	return writeErr
+}
+
+// resultsTTY reports whether results are written to a terminal
+func resultsTTY() bool {
+	f, ok := resultsOutput.(*os.File)
+	return ok && isTerminal(f)
+}
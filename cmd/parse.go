@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"fmt"
+
+	"graphql-validation-tool/internal/engine"
+
+	"github.com/chirino/graphql/schema"
+	"github.com/spf13/cobra"
+)
+
+// ParsedDocument describes the structure of a GraphQL document
+type ParsedDocument struct {
+	Path       string            `json:"path"`
+	Operations []ParsedOperation `json:"operations"`
+	Fragments  []ParsedFragment  `json:"fragments"`
+}
+
+// ParsedOperation describes one operation of a document
+type ParsedOperation struct {
+	Type      string            `json:"type"`
+	Name      string            `json:"name"`
+	Variables []CatalogVariable `json:"variables"`
+	Fields    []ParsedField     `json:"fields"`
+	// Fragments lists the fragments the operation spreads, directly or
+	// through other fragments, in the order they are first used
+	Fragments []string `json:"fragments"`
+}
+
+// ParsedField is a top-level field selected by an operation
+type ParsedField struct {
+	Name  string `json:"name"`
+	Alias string `json:"alias,omitempty"`
+}
+
+// ParsedFragment describes a fragment definition of a document
+type ParsedFragment struct {
+	Name      string   `json:"name"`
+	On        string   `json:"on"`
+	Fragments []string `json:"fragments"`
+}
+
+var parseCmd = &cobra.Command{
+	Use:   "parse",
+	Short: "Print the structure of a query as JSON",
+	Long: `Parse a GraphQL query file and print its structure as JSON, without
+connecting to a database.
+
+For each operation the output lists its type, name, declared variables,
+top-level fields and the fragments it uses, followed by the document's
+fragment definitions. Unlike lint, which reports problems, parse reports
+what the document contains, for editor integrations and other tooling.
+
+Examples:
+  # Print the structure of a query
+  gql-validate parse -f ./queries/get_user.graphql
+
+  # Write it on a single line
+  gql-validate parse -f ./queries/get_user.graphql --compact`,
+	Args: cobra.NoArgs,
+	RunE: runParse,
+}
+
+func init() {
+	rootCmd.AddCommand(parseCmd)
+
+	parseCmd.Flags().StringVarP(&queryFile, "file", "f", "", "GraphQL file to parse")
+	parseCmd.MarkFlagRequired("file")
+}
+
+func runParse(cmd *cobra.Command, args []string) error {
+	query, source, err := readMappedQueryFile(queryFile)
+	if err != nil {
+		return fmt.Errorf("failed to read query file: %w", err)
+	}
+
+	doc, err := engine.ParseDocument(query)
+	if err != nil {
+		if msg := engine.PositionedSyntaxError(queryFile, query, source); msg != "" {
+			return fmt.Errorf("failed to parse query: %s", msg)
+		}
+		return fmt.Errorf("failed to parse query: %w", err)
+	}
+
+	jsonData, err := marshalOutput(describeDocument(queryFile, doc))
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(jsonData))
+	return nil
+}
+
+// describeDocument lists the operations and fragments of a parsed document
+func describeDocument(path string, doc *schema.QueryDocument) ParsedDocument {
+	parsed := ParsedDocument{
+		Path:       path,
+		Operations: make([]ParsedOperation, 0, len(doc.Operations)),
+		Fragments:  make([]ParsedFragment, 0, len(doc.Fragments)),
+	}
+
+	for _, op := range doc.Operations {
+		operation := ParsedOperation{
+			Type:      string(op.Type),
+			Name:      engine.DisplayOperationName(op.Name),
+			Variables: catalogVariables(op.Vars),
+			Fields:    []ParsedField{},
+			Fragments: usedFragments(doc, op.Selections),
+		}
+		for _, field := range engine.SelectionFields(doc, op.Selections) {
+			parsed := ParsedField{Name: field.Name}
+			if field.Alias != field.Name {
+				parsed.Alias = field.Alias
+			}
+			operation.Fields = append(operation.Fields, parsed)
+		}
+		parsed.Operations = append(parsed.Operations, operation)
+	}
+
+	for _, frag := range doc.Fragments {
+		parsed.Fragments = append(parsed.Fragments, ParsedFragment{
+			Name:      frag.Name,
+			On:        frag.On.Name,
+			Fragments: usedFragments(doc, frag.Selections),
+		})
+	}
+
+	return parsed
+}
+
+// usedFragments returns the names of the fragments spread anywhere in sels,
+// following spreads into the fragments defined in the document
+func usedFragments(doc *schema.QueryDocument, sels schema.SelectionList) []string {
+	names := []string{}
+	seen := make(map[string]bool)
+
+	var walk func(sels schema.SelectionList)
+	walk = func(sels schema.SelectionList) {
+		for _, sel := range sels {
+			switch s := sel.(type) {
+			case *schema.FieldSelection:
+				walk(s.Selections)
+			case *schema.InlineFragment:
+				walk(s.Selections)
+			case *schema.FragmentSpread:
+				if seen[s.Name] {
+					continue
+				}
+				seen[s.Name] = true
+				names = append(names, s.Name)
+				if frag := doc.Fragments.Get(s.Name); frag != nil {
+					walk(frag.Selections)
+				}
+			}
+		}
+	}
+
+	walk(sels)
+	return names
+}
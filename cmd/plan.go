@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"graphql-validation-tool/internal/engine"
+)
+
+// listOnly prints the files validate would process and exits without
+// connecting to a database
+var listOnly bool
+
+// PlannedQuery is a query validate would process, as printed by --list-only
+type PlannedQuery struct {
+	Path          string `json:"path"`
+	VariablesFile string `json:"variables_file,omitempty"`
+	Operation     string `json:"operation,omitempty"`
+	OperationName string `json:"operation_name,omitempty"`
+	Skipped       bool   `json:"skipped,omitempty"`
+}
+
+// planQueries describes the resolved query files without running them
+func planQueries(queryFiles []string) []PlannedQuery {
+	plan := make([]PlannedQuery, 0, len(queryFiles))
+	for _, qf := range queryFiles {
+		planned := PlannedQuery{Path: qf, Skipped: isSkipped(qf, skipPatterns)}
+
+		query := ""
+		if input, ok := inlineQueries[qf]; ok {
+			query = input.Query
+		} else {
+			planned.VariablesFile = findVariablesFile(qf)
+			query, _ = readQueryFile(qf)
+		}
+		planned.Operation, planned.OperationName, _ = engine.DescribeOperation(query)
+
+		plan = append(plan, planned)
+	}
+	return plan
+}
+
+// printPlan writes the --list-only plan as JSON or text
+func printPlan(plan []PlannedQuery) error {
+	if outputFormat == "json" {
+		jsonData, err := marshalOutput(plan)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(resultsOutput, string(jsonData))
+		return nil
+	}
+
+	for _, planned := range plan {
+		var details []string
+		if planned.Operation != "" {
+			details = append(details, planned.Operation+" "+planned.OperationName)
+		}
+		if planned.VariablesFile != "" {
+			details = append(details, "vars: "+planned.VariablesFile)
+		}
+		if planned.Skipped {
+			details = append(details, "skipped")
+		}
+
+		line := planned.Path
+		if len(details) > 0 {
+			line += "  " + dim("("+strings.Join(details, ", ")+")")
+		}
+		fmt.Fprintln(resultsOutput, line)
+	}
+	fmt.Fprintf(resultsOutput, "\n%d query file(s) would be validated\n", len(plan))
+	return nil
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// progress renders a single updating status line on stderr while queries
+// are validated. It is safe for concurrent use and does nothing when
+// disabled.
+type progress struct {
+	mu       sync.Mutex
+	enabled  bool
+	total    int
+	done     int
+	lastLine int
+}
+
+// newProgress creates a progress line for total queries. It is only enabled
+// for text output on a terminal, and not in verbose mode where it would
+// interleave with debug output.
+func newProgress(total int) *progress {
+	return &progress{
+		enabled: outputFormat == "text" && isTerminal(os.Stderr) && activeLogLevel > levelDebug,
+		total:   total,
+	}
+}
+
+// Start shows that the named query is being validated
+func (p *progress) Start(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.render(fmt.Sprintf("[%d/%d] validating %s", p.done+1, p.total, name))
+}
+
+// Done records that a query finished validating
+func (p *progress) Done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.done++
+}
+
+// Clear erases the progress line so results can be printed cleanly
+func (p *progress) Clear() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.render("")
+}
+
+// render overwrites the current line; callers must hold the lock
+func (p *progress) render(line string) {
+	if !p.enabled {
+		return
+	}
+
+	padding := ""
+	if n := p.lastLine - len(line); n > 0 {
+		padding = strings.Repeat(" ", n)
+	}
+	fmt.Fprintf(os.Stderr, "\r%s%s\r", line, padding)
+	p.lastLine = len(line)
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"path/filepath"
+
+	"graphql-validation-tool/internal/engine"
+
+	"github.com/chirino/graphql/schema"
+	graphjin "github.com/dosco/graphjin/core"
+)
+
+// denyMutations rejects queries containing mutations before any are run
+var denyMutations bool
+
+// deniedMutationError explains why a query was rejected by --deny-mutations
+const deniedMutationError = "Mutation not allowed: this run uses --deny-mutations, so mutation operations are rejected before reaching the database"
+
+// rejectMutations parses every query up front and splits out those that
+// contain a mutation, returning the remaining files and a summary of the
+// rejected ones
+func rejectMutations(queryFiles []string) ([]string, ValidationSummary) {
+	return rejectMutationsWhere(queryFiles, deniedMutationError, func(string) bool { return true })
+}
+
+// rejectMutationsWhere is rejectMutations for the files deny selects,
+// failing them with message
+func rejectMutationsWhere(queryFiles []string, message string, deny func(path string) bool) ([]string, ValidationSummary) {
+	var denied ValidationSummary
+	allowed := queryFiles[:0]
+
+	for _, qf := range queryFiles {
+		if !deny(qf) {
+			allowed = append(allowed, qf)
+			continue
+		}
+
+		name, query := filepath.Base(qf), ""
+		if input, ok := inlineQueries[qf]; ok {
+			name, query = input.Name, input.Query
+		} else if content, err := readQueryFile(qf); err == nil {
+			query = content
+		}
+
+		if !containsMutation(query) {
+			allowed = append(allowed, qf)
+			continue
+		}
+
+		errs := []string{message}
+		denied.Total++
+		denied.Failed++
+		denied.Results = append(denied.Results, TestResult{
+			Name:         name,
+			Path:         qf,
+			Operation:    "mutation",
+			Errors:       errs,
+			ErrorDetails: engine.StructuredErrors(errs),
+		})
+	}
+
+	return allowed, denied
+}
+
+// containsMutation reports whether any operation in a document is a
+// mutation. Documents that fail to parse are left for validation to report.
+func containsMutation(query string) bool {
+	doc, err := engine.ParseDocument(query)
+	if err != nil {
+		h, err := graphjin.Operation(query)
+		return err == nil && h.Type == graphjin.OpMutation
+	}
+
+	for _, op := range doc.Operations {
+		if op.Type == schema.Mutation {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"net/url"
+	"slices"
+
+	"graphql-validation-tool/internal/engine"
+)
+
+// knownSecrets holds the secret values of every loaded database config
+var knownSecrets []string
+
+// registerSecrets records a database config's password and key path so
+// they are masked wherever they are printed
+func registerSecrets(db DatabaseConfig) {
+	for _, s := range engine.Secrets(db) {
+		if !slices.Contains(knownSecrets, s) {
+			knownSecrets = append(knownSecrets, s)
+		}
+	}
+}
+
+// redactSecrets masks passwords and key paths in DSNs, URLs and any
+// registered secret values in a message
+func redactSecrets(s string) string {
+	return engine.Redact(s, knownSecrets)
+}
+
+// redactedError masks secrets in an error's message while keeping the
+// original error available to errors.Is and errors.As
+type redactedError struct {
+	err error
+}
+
+func (e *redactedError) Error() string {
+	return redactSecrets(e.err.Error())
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// redactError wraps err so its message has secrets masked
+func redactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &redactedError{err: err}
+}
+
+// redactedDSN returns a connection string with its secrets masked, for display
+func redactedDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" {
+		dsn = u.Redacted()
+	}
+	return redactSecrets(dsn)
+}
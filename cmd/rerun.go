@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// rerunFailedFile is a JSON report from a previous run whose failed
+// queries are validated again
+var rerunFailedFile string
+
+// loadFailedPaths reads a JSON validation report and returns the paths of
+// its failed queries in report order, once each even when they failed on
+// several databases. Skipped queries are not included.
+func loadFailedPaths(path string) (map[string]bool, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read report: %w", err)
+	}
+
+	var summary ValidationSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse report %s (expected JSON output of validate): %w", path, err)
+	}
+
+	failed := make(map[string]bool)
+	var paths []string
+	for _, result := range summary.Results {
+		if result.Passed || result.Skipped || failed[result.Path] {
+			continue
+		}
+		failed[result.Path] = true
+		paths = append(paths, result.Path)
+	}
+
+	return failed, paths, nil
+}
+
+// selectFailedFiles keeps the query files that failed in the --rerun-failed
+// report
+func selectFailedFiles(queryFiles []string, failed map[string]bool) []string {
+	selected := queryFiles[:0]
+	for _, qf := range queryFiles {
+		if failed[qf] {
+			selected = append(selected, qf)
+		}
+	}
+	return selected
+}
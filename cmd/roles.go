@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"graphql-validation-tool/internal/engine"
+)
+
+// validateRoles runs every query once per GraphJin role for --roles
+var validateRoles []string
+
+// queryRoles returns the roles each query is run as: the --roles list, or a
+// single empty role meaning GraphJin's default
+func queryRoles() []string {
+	if len(validateRoles) == 0 {
+		return []string{""}
+	}
+	return validateRoles
+}
+
+// allowedRoles returns the roles a query's "# allowed-roles:" header permits,
+// or nil when it has none
+func allowedRoles(queryPath string) []string {
+	query := ""
+	if input, ok := inlineQueries[queryPath]; ok {
+		query = input.Query
+	} else {
+		query, _ = readQueryFile(queryPath)
+	}
+	return engine.ParseHeader(query).AllowedRoles
+}
+
+// permissionErrorPattern matches the errors GraphJin and PostgreSQL report
+// when a role may not use a table, column or function
+var permissionErrorPattern = regexp.MustCompile(`(?i)\bblocked\b|permission denied`)
+
+// permissionDenied reports whether a failure's errors are all permission
+// errors, as opposed to a broken query or an unreachable database
+func permissionDenied(errs []string) bool {
+	if len(errs) == 0 {
+		return false
+	}
+	for _, msg := range errs {
+		if !permissionErrorPattern.MatchString(msg) {
+			return false
+		}
+	}
+	return true
+}
+
+// applyAllowedRoles checks a result run as role against the query's allowed
+// roles. Allowed roles must pass as usual. Any other role must be denied
+// permission; when it is, the result passes as an expected denial, when it
+// succeeds, the result fails, and when it fails for another reason, the
+// failure is kept.
+func applyAllowedRoles(result TestResult, role string, allowed []string) TestResult {
+	result.Role = role
+	if allowed == nil || role == "" {
+		return result
+	}
+	for _, r := range allowed {
+		if r == role {
+			return result
+		}
+	}
+
+	if result.Passed {
+		result.Passed = false
+		result.Errors = append(result.Errors, fmt.Sprintf("Role not allowed: %q succeeded but is not in allowed-roles (%s)",
+			role, strings.Join(allowed, ", ")))
+	} else if permissionDenied(result.Errors) {
+		result.Passed = true
+		result.Denied = true
+		result.Errors = nil
+	}
+	result.ErrorDetails = engine.StructuredErrors(result.Errors)
+	return result
+}
+
+// printRoleMatrix prints which roles each query succeeded and failed as,
+// marking expected denials
+func printRoleMatrix(results []TestResult) {
+	width := len("query")
+	var rows []string
+	cells := make(map[string]map[string]TestResult)
+	for _, result := range results {
+		row := result.Path
+		if result.Target != "" {
+			row = fmt.Sprintf("[%s] %s", result.Target, row)
+		}
+		if cells[row] == nil {
+			cells[row] = make(map[string]TestResult)
+			rows = append(rows, row)
+			if len(row) > width {
+				width = len(row)
+			}
+		}
+		cells[row][result.Role] = result
+	}
+
+	fmt.Fprintf(resultsOutput, "  %-*s", width, "query")
+	for _, role := range validateRoles {
+		fmt.Fprintf(resultsOutput, "  %-8s", role)
+	}
+	fmt.Fprintln(resultsOutput)
+
+	for _, row := range rows {
+		fmt.Fprintf(resultsOutput, "  %-*s", width, row)
+		for _, role := range validateRoles {
+			result, ok := cells[row][role]
+			// Pad before coloring so escape codes do not skew the columns
+			switch {
+			case !ok:
+				fmt.Fprintf(resultsOutput, "  %-8s", "-")
+			case result.Denied:
+				fmt.Fprint(resultsOutput, "  "+dim(fmt.Sprintf("%-8s", "denied")))
+			case result.Passed:
+				fmt.Fprint(resultsOutput, "  "+green(fmt.Sprintf("%-8s", "ok")))
+			default:
+				fmt.Fprint(resultsOutput, "  "+red(fmt.Sprintf("%-8s", "FAIL")))
+			}
+		}
+		fmt.Fprintln(resultsOutput)
+	}
+	fmt.Fprintln(resultsOutput)
+}
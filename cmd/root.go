@@ -1,17 +1,36 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
+	"graphql-validation-tool/internal/engine"
+
 	"github.com/spf13/cobra"
 )
 
 var (
 	// Global flags
-	cfgFile    string
-	verbose    bool
-	jsonOutput bool
+	cfgFile      string
+	verbose      bool
+	jsonOutput   bool
+	noColor      bool
+	logLevelName string
+
+	// graphjinDebug and productionMode override the config's graphjin_debug
+	// and production settings when the flags are given
+	graphjinDebug  bool
+	productionMode bool
+
+	// dsnOverride replaces the configured database connection settings
+	dsnOverride string
+
+	// allowMissing turns a missing or empty queries directory into a warning
+	allowMissing bool
+
+	// queryExtensions lists the file extensions recognized as query files
+	queryExtensions = []string{".graphql"}
 
 	// Version info
 	Version   = "1.0.0"
@@ -40,21 +59,59 @@ Examples:
   # Initialize a new project with sample config
   gql-validate init`,
 	Version: Version,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		queryExtensions = engine.NormalizeExtensions(queryExtensions)
+		if len(queryExtensions) == 0 {
+			return fmt.Errorf("at least one query file extension is required")
+		}
+		if !cmd.Flags().Changed("config") && os.Getenv(configEnvVar) != "" {
+			cfgFile = envConfigPath
+		}
+		setupRandom(cmd.Flags().Changed("seed"))
+		return setupLogging()
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, redactSecrets(err.Error()))
+
+		var exitErr *exitCodeError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
 		os.Exit(1)
 	}
 }
 
+// exitCodeError fails the process with a specific exit status
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+func (e *exitCodeError) Error() string { return e.err.Error() }
+
+func (e *exitCodeError) Unwrap() error { return e.err }
+
 func init() {
 	// Global flags available to all commands
-	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "config.yaml", "config file path")
+	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "config.yaml", "config file path, or - to read the config from stdin")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "enable verbose output")
 	rootCmd.PersistentFlags().BoolVarP(&jsonOutput, "json", "j", false, "output results as JSON")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "disable colored output (also honors NO_COLOR)")
+	rootCmd.PersistentFlags().StringSliceVar(&queryExtensions, "ext", queryExtensions, "query file extensions to recognize (repeatable)")
+	rootCmd.PersistentFlags().StringVar(&logLevelName, "log-level", "info", "diagnostic log level written to stderr (debug, info, warn, error)")
+	rootCmd.PersistentFlags().BoolVar(&compactJSON, "compact", false, "write JSON output on a single line")
+	rootCmd.PersistentFlags().IntVar(&jsonIndent, "indent", 2, "number of spaces to indent JSON output with")
+	rootCmd.MarkFlagsMutuallyExclusive("compact", "indent")
+	rootCmd.PersistentFlags().BoolVar(&allowMissing, "allow-missing", false, "exit successfully with a warning when the queries directory is missing or has no query files")
+	rootCmd.PersistentFlags().StringVar(&variablesEnv, "env", "", "merge each query's <name>.<env>.json variables override over its variables file")
+	rootCmd.PersistentFlags().Int64Var(&randomSeed, "seed", 0, "seed for random values in variables files (default: random, printed when used)")
+	rootCmd.PersistentFlags().StringVar(&dsnOverride, "dsn", "", "database connection URL, overriding the config file and DB_URL")
+	rootCmd.PersistentFlags().BoolVar(&graphjinDebug, "graphjin-debug", false, "enable GraphJin debug logging (overrides graphjin_debug in the config)")
+	rootCmd.PersistentFlags().BoolVar(&productionMode, "production", false, "run GraphJin in production mode (overrides production in the config)")
 
 	// Set version template
 	rootCmd.SetVersionTemplate(`{{printf "gql-validate version %s\n" .Version}}`)
@@ -0,0 +1,290 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"graphql-validation-tool/internal/engine"
+
+	graphjin "github.com/dosco/graphjin/core"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	scenarioFiles []string
+
+	// scenarioSteps holds the steps of the --scenario files, keyed by the
+	// pseudo path of their query in inlineQueries
+	scenarioSteps map[string]scenarioStep
+)
+
+// Scenario is a YAML file of query steps run in order, where later steps
+// can use values returned by earlier ones
+type Scenario struct {
+	Steps []ScenarioStep `yaml:"steps"`
+}
+
+// ScenarioStep is a single query of a scenario, given inline or as a file
+// relative to the scenario
+type ScenarioStep struct {
+	Name      string                 `yaml:"name"`
+	Query     string                 `yaml:"query"`
+	File      string                 `yaml:"file"`
+	Variables map[string]interface{} `yaml:"variables"`
+	Role      string                 `yaml:"role"`
+	Operation string                 `yaml:"operation"`
+}
+
+// scenarioStep tracks a step's place in its scenario while the run threads
+// results through it
+type scenarioStep struct {
+	scenario string
+	name     string
+}
+
+// stepRefPattern matches a "$steps.<step>.<path>" reference to a value an
+// earlier step returned, where the path is made of .keys and [indexes]
+var stepRefPattern = regexp.MustCompile(`^\$steps\.([A-Za-z0-9_-]+)((?:\.[A-Za-z0-9_-]+|\[\d+\])*)$`)
+
+// stepPathSegment matches one .key or [index] of a reference's path
+var stepPathSegment = regexp.MustCompile(`\.([A-Za-z0-9_-]+)|\[(\d+)\]`)
+
+// scenarioRun holds what the steps run so far returned, keyed by scenario
+// and step name, and the step each scenario failed at
+type scenarioRun struct {
+	data   map[string]map[string]map[string]interface{}
+	failed map[string]string
+}
+
+// loadScenarios reads the scenario files, returning the pseudo paths of
+// their steps in run order along with the steps' queries keyed by those
+// paths. Each step's path is "<scenario>#<name>".
+func loadScenarios(paths []string) ([]string, map[string]queryInput, error) {
+	var stepPaths []string
+	inputs := make(map[string]queryInput)
+	scenarioSteps = make(map[string]scenarioStep)
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read scenario: %w", err)
+		}
+
+		var scenario Scenario
+		if err := yaml.UnmarshalStrict(data, &scenario); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse scenario %s: %w", path, err)
+		}
+		if len(scenario.Steps) == 0 {
+			return nil, nil, fmt.Errorf("scenario %s defines no steps", path)
+		}
+
+		for i, step := range scenario.Steps {
+			input, err := scenarioInput(path, step)
+			if err != nil {
+				if step.Name == "" {
+					return nil, nil, fmt.Errorf("%s: steps[%d]: %w", path, i, err)
+				}
+				return nil, nil, fmt.Errorf("%s: step %s: %w", path, step.Name, err)
+			}
+			if _, ok := inputs[input.Path]; ok {
+				return nil, nil, fmt.Errorf("%s: step %s: duplicate name", path, step.Name)
+			}
+
+			stepPaths = append(stepPaths, input.Path)
+			inputs[input.Path] = input
+			scenarioSteps[input.Path] = scenarioStep{scenario: path, name: step.Name}
+		}
+	}
+
+	return stepPaths, inputs, nil
+}
+
+// scenarioInput builds the query of a step. Its variables may still hold
+// step references, resolved once the earlier steps have run.
+func scenarioInput(scenarioPath string, step ScenarioStep) (queryInput, error) {
+	if step.Name == "" {
+		return queryInput{}, fmt.Errorf("name is required")
+	}
+	if (step.Query == "") == (step.File == "") {
+		return queryInput{}, fmt.Errorf("exactly one of query and file is required")
+	}
+
+	input := queryInput{
+		Name:      step.Name,
+		Path:      scenarioPath + "#" + step.Name,
+		Query:     step.Query,
+		Role:      step.Role,
+		Operation: step.Operation,
+	}
+
+	variables, err := manifestVariables(validationCtx, input.Path, step.Variables)
+	if err != nil {
+		return queryInput{}, err
+	}
+	input.Variables = variables
+
+	if step.File != "" {
+		queryPath := filepath.Join(filepath.Dir(scenarioPath), step.File)
+		if input.Query, input.Source, err = readMappedQueryFile(queryPath); err != nil {
+			return queryInput{}, fmt.Errorf("failed to read query file: %w", err)
+		}
+		// The query's own variables file applies when the step sets none
+		if len(step.Variables) == 0 {
+			if input.Variables, _, err = loadVariables(validationCtx, queryPath); err != nil {
+				return queryInput{}, fmt.Errorf("failed to load variables: %w", err)
+			}
+		}
+	}
+
+	return input, nil
+}
+
+// newScenarioRun starts threading results through the scenarios
+func newScenarioRun() *scenarioRun {
+	return &scenarioRun{
+		data:   make(map[string]map[string]map[string]interface{}),
+		failed: make(map[string]string),
+	}
+}
+
+// validateStep runs a scenario step with the values of earlier steps
+// filled into its variables. Once a step fails, the rest of its scenario
+// is skipped.
+func (r *scenarioRun) validateStep(gj *graphjin.GraphJin, input queryInput, step scenarioStep) TestResult {
+	if failed, ok := r.failed[step.scenario]; ok {
+		return TestResult{
+			Name:     input.Name,
+			Path:     input.Path,
+			Skipped:  true,
+			Warnings: []string{fmt.Sprintf("Not run: scenario step %s failed", failed)},
+		}
+	}
+
+	variables, err := r.resolveVariables(step.scenario, input.Variables)
+	if err != nil {
+		r.failed[step.scenario] = step.name
+		errs := []string{fmt.Sprintf("Failed to load variables: %v", err)}
+		return TestResult{Name: input.Name, Path: input.Path, Errors: errs, ErrorDetails: engine.StructuredErrors(errs)}
+	}
+	input.Variables = variables
+
+	result := validateInputSafely(gj, input)
+	if !result.Passed {
+		r.failed[step.scenario] = step.name
+		return result
+	}
+
+	var data map[string]interface{}
+	_ = json.Unmarshal(result.Data, &data)
+	if r.data[step.scenario] == nil {
+		r.data[step.scenario] = make(map[string]map[string]interface{})
+	}
+	r.data[step.scenario][step.name] = data
+	return result
+}
+
+// resolveVariables replaces each string variable value that is a step
+// reference with the value it refers to
+func (r *scenarioRun) resolveVariables(scenario string, variables json.RawMessage) (json.RawMessage, error) {
+	if !strings.Contains(string(variables), "$steps.") {
+		return variables, nil
+	}
+
+	var values interface{}
+	if err := json.Unmarshal(variables, &values); err != nil {
+		return nil, err
+	}
+	values, err := r.resolveValue(scenario, values)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(values)
+}
+
+func (r *scenarioRun) resolveValue(scenario string, v interface{}) (interface{}, error) {
+	var err error
+	switch v := v.(type) {
+	case string:
+		if m := stepRefPattern.FindStringSubmatch(v); m != nil {
+			return r.lookup(scenario, v, m[1], m[2])
+		}
+	case map[string]interface{}:
+		for key, value := range v {
+			if v[key], err = r.resolveValue(scenario, value); err != nil {
+				return nil, err
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			if v[i], err = r.resolveValue(scenario, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return v, nil
+}
+
+// lookup follows a reference's path through the data a step returned. A
+// key applied to a list looks in its first element, and a key missing
+// from a response with a single top-level field is looked up inside that
+// field, so $steps.create_user.id finds {"users": [{"id": 1}]}'s id.
+func (r *scenarioRun) lookup(scenario, ref, stepName, path string) (interface{}, error) {
+	data, ok := r.data[scenario][stepName]
+	if !ok {
+		return nil, fmt.Errorf("%s: step %s has not run before this one", ref, stepName)
+	}
+
+	var value interface{} = data
+	for i, seg := range stepPathSegment.FindAllStringSubmatch(path, -1) {
+		if list, ok := value.([]interface{}); ok && seg[1] != "" {
+			if len(list) == 0 {
+				return nil, fmt.Errorf("%s: %s returned an empty list", ref, stepName)
+			}
+			value = list[0]
+		}
+
+		switch current := value.(type) {
+		case map[string]interface{}:
+			if seg[1] == "" {
+				return nil, fmt.Errorf("%s: cannot index an object", ref)
+			}
+			next, found := current[seg[1]]
+			if !found && i == 0 && len(current) == 1 {
+				next, found = lookupInOnlyField(current, seg[1])
+			}
+			if !found {
+				return nil, fmt.Errorf("%s: %s returned no %q field", ref, stepName, seg[1])
+			}
+			value = next
+		case []interface{}:
+			index, _ := strconv.Atoi(seg[2])
+			if index >= len(current) {
+				return nil, fmt.Errorf("%s: %s returned only %d item(s)", ref, stepName, len(current))
+			}
+			value = current[index]
+		default:
+			return nil, fmt.Errorf("%s: %s returned no value at this path", ref, stepName)
+		}
+	}
+	return value, nil
+}
+
+// lookupInOnlyField looks key up inside the single top-level field of a
+// response, or in its first element when it is a list
+func lookupInOnlyField(data map[string]interface{}, key string) (interface{}, bool) {
+	for _, only := range data {
+		if list, ok := only.([]interface{}); ok && len(list) > 0 {
+			only = list[0]
+		}
+		if fields, ok := only.(map[string]interface{}); ok {
+			value, found := fields[key]
+			return value, found
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,9 @@
+package cmd
+
+// schemaFile validates queries against this schema snapshot instead of a
+// live database
+var schemaFile string
+
+// offlineSchema holds the columns of each table in the --schema-file
+// snapshot; it is nil when validating against a database
+var offlineSchema map[string][]string
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"time"
+
+	"graphql-validation-tool/internal/engine"
+)
+
+// randomSeed seeds all randomized variable generation, so a run that uses
+// random values can be reproduced with --seed
+var randomSeed int64
+
+// setupRandom seeds the run's random values from --seed, or from the clock
+// when the flag is not given, and adds them to validationCtx
+func setupRandom(seedGiven bool) {
+	if !seedGiven {
+		randomSeed = time.Now().UnixNano()
+	}
+	validationCtx = engine.WithRandom(validationCtx, randomSeed)
+}
+
+// reportSeed logs the seed of a run that generated random values, so a
+// failure can be reproduced from CI logs
+func reportSeed() {
+	if engine.RandomUsed(validationCtx) {
+		logInfo("Random variables were generated with seed %d (reproduce with --seed %d)", randomSeed, randomSeed)
+	}
+}
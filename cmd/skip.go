@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// loadSkipFile reads known-failure entries, one path or glob per line.
+// Blank lines and lines starting with # are ignored.
+func loadSkipFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read skip file: %w", err)
+	}
+	defer file.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, err := filepath.Match(line, ""); err != nil {
+			return nil, fmt.Errorf("invalid skip pattern %q: %w", line, err)
+		}
+		patterns = append(patterns, filepath.Clean(line))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read skip file: %w", err)
+	}
+
+	return patterns, nil
+}
+
+// isSkipped reports whether a query path matches any skip pattern, either
+// by its full path or by its file name
+func isSkipped(queryPath string, patterns []string) bool {
+	path := filepath.Clean(queryPath)
+	name := filepath.Base(path)
+
+	for _, pattern := range patterns {
+		if pattern == path {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"graphql-validation-tool/internal/engine"
+)
+
+var (
+	// includeTags and excludeTags select queries by the tags in their header
+	includeTags []string
+	excludeTags []string
+)
+
+// tagsSelected reports whether a query with the given tags matches
+// --tag (any of) and --exclude-tag (none of)
+func tagsSelected(tags []string) bool {
+	has := func(wanted []string) bool {
+		for _, w := range wanted {
+			for _, tag := range tags {
+				if strings.EqualFold(tag, w) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	if len(includeTags) > 0 && !has(includeTags) {
+		return false
+	}
+	return !has(excludeTags)
+}
+
+// selectTaggedFiles filters query files by the tags in their headers
+func selectTaggedFiles(queryFiles []string) ([]string, error) {
+	if len(includeTags) == 0 && len(excludeTags) == 0 {
+		return queryFiles, nil
+	}
+
+	selected := queryFiles[:0]
+	for _, qf := range queryFiles {
+		content, err := engine.ReadQuerySource(qf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", qf, err)
+		}
+		if tagsSelected(engine.ParseHeader(content).Tags) {
+			selected = append(selected, qf)
+		}
+	}
+	return selected, nil
+}
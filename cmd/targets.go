@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"graphql-validation-tool/internal/engine"
+)
+
+// targetSchema holds the tables and foreign keys of the database currently
+// being validated, for the relationship and deprecation checks
+var targetSchema *engine.Schema
+
+// validationTarget is a database the query suite is validated against
+type validationTarget struct {
+	name   string
+	config *Config
+}
+
+// resolveTargets returns the databases to validate against: the configured
+// database, or every entry in the databases list with --all-databases
+func resolveTargets(config *Config) ([]validationTarget, error) {
+	if !allDatabases {
+		if err := config.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid configuration: %w", err)
+		}
+		return []validationTarget{{config: config}}, nil
+	}
+
+	if len(config.Databases) == 0 {
+		return nil, fmt.Errorf("--all-databases requires a databases list in the config file")
+	}
+
+	seen := make(map[string]bool)
+	targets := make([]validationTarget, 0, len(config.Databases))
+	for i, db := range config.Databases {
+		if db.Name == "" {
+			return nil, fmt.Errorf("databases[%d]: name is required", i)
+		}
+		if seen[db.Name] {
+			return nil, fmt.Errorf("databases[%d]: duplicate name %q", i, db.Name)
+		}
+		seen[db.Name] = true
+
+		targetConfig, err := config.ForTarget(db)
+		if err != nil {
+			return nil, err
+		}
+		registerSecrets(targetConfig.Database)
+		if err := targetConfig.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid configuration for target %s: %w", db.Name, err)
+		}
+		targets = append(targets, validationTarget{name: db.Name, config: targetConfig})
+	}
+
+	return targets, nil
+}
+
+// validateTarget runs the query suite against a single target, after
+// priorFailures failures on earlier targets
+func validateTarget(target validationTarget, queryFiles []string, priorFailures int) (ValidationSummary, error) {
+	if target.name != "" {
+		logInfo("Validating against %s", target.name)
+	}
+
+	// Schema data read for an earlier target must not be checked against
+	// this one, even when reading this target's schema fails
+	targetSchema = &engine.Schema{}
+
+	// A schema snapshot stands in for the database
+	if offlineSchema != nil {
+		return validateQueries(nil, queryFiles, target.name, priorFailures), nil
+	}
+
+	// Seed before GraphJin reads the schema; teardown runs even on failure
+	teardown, err := runFixtures(target.config)
+	defer teardown()
+	if err != nil {
+		if target.name != "" {
+			return ValidationSummary{}, fmt.Errorf("%s: %w", target.name, err)
+		}
+		return ValidationSummary{}, err
+	}
+
+	gj, db, err := initializeGraphJin(target.config)
+	if err != nil {
+		if target.name != "" {
+			return ValidationSummary{}, fmt.Errorf("failed to initialize GraphJin for %s: %w", target.name, err)
+		}
+		return ValidationSummary{}, fmt.Errorf("failed to initialize GraphJin: %w", err)
+	}
+	defer db.Close()
+
+	if err := targetSchema.LoadRelationships(db, target.config); err != nil {
+		logWarn("Skipping the relationship check: %v", redactError(err))
+	}
+	if checkDeprecated {
+		if err := targetSchema.LoadDeprecatedColumns(db, target.config.Database.Schema); err != nil {
+			logWarn("Skipping the deprecated column check: %v", redactError(err))
+		}
+	}
+
+	return validateQueries(gj, queryFiles, target.name, priorFailures), nil
+}
+
+// mergeSummaries adds the counts and results of next onto summary
+func mergeSummaries(summary, next ValidationSummary) ValidationSummary {
+	summary.Total += next.Total
+	summary.Passed += next.Passed
+	summary.Failed += next.Failed
+	summary.Skipped += next.Skipped
+	summary.UnexpectedPasses += next.UnexpectedPasses
+	summary.Warnings += next.Warnings
+	summary.Interrupted = summary.Interrupted || next.Interrupted
+	summary.StoppedEarly = summary.StoppedEarly || next.StoppedEarly
+	summary.Results = append(summary.Results, next.Results...)
+	return summary
+}
+
+// findInconsistent returns the paths of queries that pass on some targets
+// and fail on others
+func findInconsistent(results []TestResult) []string {
+	passed := make(map[string]bool)
+	failed := make(map[string]bool)
+	for _, result := range results {
+		if result.Skipped {
+			continue
+		}
+		// With --roles, outcomes are only comparable within a role
+		key := result.Path
+		if result.Role != "" {
+			key = fmt.Sprintf("%s (%s)", result.Path, result.Role)
+		}
+		if result.Passed {
+			passed[key] = true
+		} else {
+			failed[key] = true
+		}
+	}
+
+	var inconsistent []string
+	for path := range failed {
+		if passed[path] {
+			inconsistent = append(inconsistent, path)
+		}
+	}
+	sort.Strings(inconsistent)
+	return inconsistent
+}
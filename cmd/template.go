@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var (
+	// templateFile is a text/template the validation summary is rendered
+	// through instead of a built-in format
+	templateFile   string
+	reportTemplate *template.Template
+)
+
+// templateFuncs are the helpers available to --template files
+var templateFuncs = template.FuncMap{
+	// duration formats a duration in milliseconds, e.g. "1.25s"
+	"duration": func(ms int64) string {
+		return (time.Duration(ms) * time.Millisecond).String()
+	},
+	// totalDuration formats the summed duration of results
+	"totalDuration": func(results []TestResult) string {
+		var total int64
+		for _, result := range results {
+			total += result.Duration
+		}
+		return (time.Duration(total) * time.Millisecond).String()
+	},
+	// failures and passes filter results by outcome; skipped results are in
+	// neither
+	"failures": func(results []TestResult) []TestResult {
+		return filterResults(results, func(r TestResult) bool { return !r.Passed && !r.Skipped })
+	},
+	"passes": func(results []TestResult) []TestResult {
+		return filterResults(results, func(r TestResult) bool { return r.Passed && !r.Skipped })
+	},
+	// passRate formats the share of non-skipped queries that passed
+	"passRate": func(summary ValidationSummary) string {
+		ran := summary.Passed + summary.Failed
+		if ran == 0 {
+			return "n/a"
+		}
+		return fmt.Sprintf("%.1f%%", 100*float64(summary.Passed)/float64(ran))
+	},
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	// json renders any value as indented JSON
+	"json": func(v interface{}) (string, error) {
+		data, err := json.MarshalIndent(v, "", "  ")
+		return string(data), err
+	},
+}
+
+// loadReportTemplate parses the --template file
+func loadReportTemplate(path string) (*template.Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+
+	tmpl, err := template.New(filepath.Base(path)).Funcs(templateFuncs).Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// filterResults returns the results keep accepts
+func filterResults(results []TestResult, keep func(TestResult) bool) []TestResult {
+	var kept []TestResult
+	for _, result := range results {
+		if keep(result) {
+			kept = append(kept, result)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"graphql-validation-tool/internal/engine"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// otelEndpoint is the OTLP/HTTP collector URL spans are exported to
+var otelEndpoint string
+
+// tracer creates the run's spans; it is a no-op until tracing is set up
+var tracer = trace.NewNoopTracerProvider().Tracer("")
+
+// tracingShutdownTimeout bounds how long exporting the last spans may delay
+// exiting
+const tracingShutdownTimeout = 5 * time.Second
+
+// setupTracing exports spans over OTLP/HTTP when --otel-endpoint or the
+// standard OTEL_EXPORTER_OTLP_* variables name a collector. GraphJin's own
+// spans are exported too, and are always recorded to time their execute
+// phase. The returned function flushes the remaining spans.
+func setupTracing() (shutdown func(), err error) {
+	if !tracingEnabled() {
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(&engine.PhaseTimer{})))
+		return func() {}, nil
+	}
+
+	var opts []otlptracehttp.Option
+	if otelEndpoint != "" {
+		opts, err = otlpEndpointOptions(otelEndpoint)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ctx := context.Background()
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the defaults
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceNameKey.String("gql-validate"), semconv.ServiceVersionKey.String(Version)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe the trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSpanProcessor(&engine.PhaseTimer{}),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logWarn("Tracing: %v", err)
+	}))
+	tracer = provider.Tracer("gql-validate")
+	logDebug("Exporting traces over OTLP")
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		if err := provider.Shutdown(ctx); err != nil {
+			logWarn("Failed to export traces: %v", err)
+		}
+	}, nil
+}
+
+// tracingEnabled reports whether a collector is configured, honoring
+// OTEL_SDK_DISABLED
+func tracingEnabled() bool {
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") {
+		return false
+	}
+	return otelEndpoint != "" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// otlpEndpointOptions converts a collector URL such as
+// http://localhost:4318 into exporter options
+func otlpEndpointOptions(endpoint string) ([]otlptracehttp.Option, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid --otel-endpoint %q (expected a URL such as http://localhost:4318)", endpoint)
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(u.Host)}
+	if u.Scheme == "http" {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	if path := strings.TrimRight(u.Path, "/"); path != "" {
+		opts = append(opts, otlptracehttp.WithURLPath(path))
+	}
+	return opts, nil
+}
+
+// startRunSpan starts the span of a validation run, making it the parent of
+// the spans started from validationCtx
+func startRunSpan() trace.Span {
+	var span trace.Span
+	validationCtx, span = tracer.Start(validationCtx, "gql-validate run")
+	return span
+}
+
+// endRunSpan records the run's totals on its span and ends it
+func endRunSpan(span trace.Span, summary ValidationSummary) {
+	span.SetAttributes(
+		attribute.Int("gql.total", summary.Total),
+		attribute.Int("gql.passed", summary.Passed),
+		attribute.Int("gql.failed", summary.Failed),
+		attribute.Int("gql.skipped", summary.Skipped),
+	)
+	if summary.Failed > 0 {
+		span.SetStatus(codes.Error, fmt.Sprintf("%d validation(s) failed", summary.Failed))
+	}
+	span.End()
+}
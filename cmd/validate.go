@@ -3,13 +3,16 @@ package cmd
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
+	"graphql-validation-tool/internal/engine"
+
 	graphjin "github.com/dosco/graphjin/core"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/spf13/cobra"
@@ -17,26 +20,83 @@ import (
 
 var (
 	queriesDir string
-	queryFile  string
-	failFast   bool
+	// queriesDirs holds validate's query directories; -q may be repeated
+	queriesDirs []string
+
+	queryFile string
+	// operationName selects the operation to run from a --file document
+	// that defines several
+	operationName string
+
+	failFast    bool
+	maxFailures int
+	exitZero    bool
+	minPassRate float64
+
+	fragmentsPath string
+	// sharedFragments holds fragment definitions loaded via --fragments
+	sharedFragments map[string]string
+
+	skipFile string
+	// skipPatterns holds the known-failure entries loaded via --skip-file
+	skipPatterns []string
+
+	outputFormat string
+	showSQL      bool
+	quiet        bool
+	groupByDir   bool
+	metricsFile  string
+	timingsFile  string
+
+	// summaryByCategory adds error counts by category to the summary
+	summaryByCategory bool
+
+	// allDatabases validates against every entry in the config's databases list
+	allDatabases bool
+
+	// continueOnConfigError records a database that cannot be set up as a
+	// target error and moves on to the next one
+	continueOnConfigError bool
+
+	// noNestedErrorScan disables looking for error keys in response data,
+	// and nestedErrorPaths restricts that scan to the given paths
+	noNestedErrorScan bool
+	nestedErrorPaths  []string
+
+	// requireNonEmpty applies the non-empty expectation to every query
+	// without its own "# expect:" header
+	requireNonEmpty bool
+
+	// queryBudget fails queries that take longer than this to run, and
+	// maxResultBytes those whose response data is larger than this many
+	// bytes; 0 disables either check
+	queryBudget    time.Duration
+	maxResultBytes int
+
+	// maxSQLStatements warns about read queries that run more than this
+	// many SQL statements; 0 disables the check
+	maxSQLStatements int
+
+	// checkDeprecated warns about queries that select columns whose
+	// database comment marks them as deprecated
+	checkDeprecated bool
+
+	// allowedDirectives are directives accepted on top of GraphJin's own,
+	// from --allow-directive and the config's validate.allowed_directives
+	allowedDirectives []string
+
+	// includePatterns and excludePatterns come from the config's validate section
+	includePatterns []string
+	excludePatterns []string
 )
 
-// TestResult represents the result of validating a single query
-type TestResult struct {
-	Name     string   `json:"name"`
-	Path     string   `json:"path"`
-	Passed   bool     `json:"passed"`
-	Errors   []string `json:"errors,omitempty"`
-	Duration int64    `json:"duration_ms"`
-}
-
-// ValidationSummary represents the overall validation results
-type ValidationSummary struct {
-	Total   int          `json:"total"`
-	Passed  int          `json:"passed"`
-	Failed  int          `json:"failed"`
-	Results []TestResult `json:"results"`
-}
+// The result types live in the engine package, shared with the validator
+// package
+type (
+	TestResult        = engine.TestResult
+	TargetError       = engine.TargetError
+	ValidationSummary = engine.ValidationSummary
+)
 
 var validateCmd = &cobra.Command{
 	Use:   "validate",
@@ -53,343 +113,1150 @@ Examples:
   # Validate all queries in a specific directory
   gql-validate validate -q ./my-queries
 
+  # Validate several directories in one report
+  gql-validate validate -q ./svc-a/queries -q ./svc-b/queries
+
   # Validate a single query file
   gql-validate validate -f ./queries/get_user.graphql
 
+  # Run one operation of a multi-operation document
+  gql-validate validate -f ./queries/users.graphql --operation GetUser
+
+  # Validate the queries defined inline in a manifest
+  gql-validate validate --manifest queries.yaml
+
+  # Run a chain of queries that pass values from one step to the next
+  gql-validate validate --scenario signup.yaml
+
+  # Re-check only the queries that failed last time
+  gql-validate validate -j --out report.json
+  gql-validate validate --rerun-failed report.json
+
   # Validate with verbose output
   gql-validate validate -v
 
+  # Show which files would be validated, without connecting
+  gql-validate validate --list-only --tag smoke
+
   # Stop on first failure
-  gql-validate validate --fail-fast`,
+  gql-validate validate --fail-fast
+
+  # Share database round trips between simple read queries
+  gql-validate validate --batch
+
+  # Report only, never fail the process
+  gql-validate validate --exit-zero
+
+  # Only fail if fewer than 95% of queries pass
+  gql-validate validate --min-pass-rate 0.95
+
+  # Resolve fragment spreads from a shared file
+  gql-validate validate --fragments ./fragments.graphql
+
+  # Report known-broken queries as skipped instead of failed
+  gql-validate validate --skip-file skips.txt
+
+  # Export results as CSV
+  gql-validate validate --format csv > results.csv
+
+  # Archive the JSON report while watching progress on the terminal
+  gql-validate validate -j --out report.json
+
+  # Export results for GitHub code scanning
+  gql-validate validate --format sarif > results.sarif
+
+  # Run only queries tagged smoke, leaving out slow ones
+  gql-validate validate --tag smoke --exclude-tag slow
+
+  # Report queries that broke or were fixed since the previous run
+  gql-validate validate --baseline .gql-baseline.json --regressions-only
+
+  # Guarantee no writes reach a read replica
+  gql-validate validate --deny-mutations
+
+  # Write Prometheus metrics for a Pushgateway
+  gql-validate validate --metrics-out metrics.prom
+
+  # Record every query's duration for a latency histogram
+  gql-validate validate --timings-out timings.csv
+
+  # Only print failures and the summary
+  gql-validate validate -Q
+
+  # Fail queries that return no rows
+  gql-validate validate --require-non-empty
+
+  # Validate against every database in the config's databases list
+  gql-validate validate --all-databases
+
+  # Keep validating the other databases when one is down
+  gql-validate validate --all-databases --continue-on-config-error
+
+  # Validate offline against a snapshot of information_schema.columns
+  gql-validate validate --schema-file schema.csv`,
 	RunE: runValidate,
 }
 
 func init() {
 	rootCmd.AddCommand(validateCmd)
 
-	validateCmd.Flags().StringVarP(&queriesDir, "queries", "q", "./queries", "directory containing GraphQL query files")
+	validateCmd.Flags().StringSliceVarP(&queriesDirs, "queries", "q", []string{"./queries"}, "directory containing GraphQL query files (repeatable or comma-separated)")
 	validateCmd.Flags().StringVarP(&queryFile, "file", "f", "", "single GraphQL file to validate")
+	validateCmd.Flags().StringVar(&operationName, "operation", "", "operation to run when the --file document defines several")
+	validateCmd.Flags().StringVar(&manifestFile, "manifest", "", "YAML manifest of named queries with inline bodies to validate")
+	validateCmd.Flags().StringSliceVar(&scenarioFiles, "scenario", nil, "run the steps of this YAML scenario in order, passing values between them (repeatable)")
+	validateCmd.Flags().BoolVar(&scanGo, "scan-go", false, "validate the string literals marked with a //gql comment in the Go files under --queries")
+	validateCmd.Flags().BoolVar(&scanMarkdown, "scan-markdown", false, "validate the graphql code blocks of the Markdown files under --queries")
+	validateCmd.Flags().BoolVar(&changedOnly, "changed-only", false, "only validate queries whose query or variables files changed since --base-ref (git)")
+	validateCmd.Flags().StringVar(&baseRef, "base-ref", "main", "git ref --changed-only compares against")
+	validateCmd.Flags().StringVar(&rerunFailedFile, "rerun-failed", "", "only validate the queries that failed in this JSON report from a previous run")
+	validateCmd.Flags().BoolVar(&denyMutations, "deny-mutations", false, "fail queries containing a mutation without sending them to the database")
+	validateCmd.Flags().BoolVar(&batchQueries, "batch", false, "combine independent read queries into shared requests to save database round trips")
+	validateCmd.Flags().BoolVar(&listOnly, "list-only", false, "print the query files that would be validated, with their variables files and operation types, without connecting")
+	validateCmd.Flags().BoolVar(&listOnly, "dry-run-discovery", false, "alias for --list-only")
+	validateCmd.Flags().MarkHidden("dry-run-discovery")
 	validateCmd.Flags().BoolVar(&failFast, "fail-fast", false, "stop on first validation failure")
+	validateCmd.Flags().IntVar(&maxFailures, "max-failures", 0, "stop the run once this many validations have failed (0 = no limit)")
+	validateCmd.Flags().BoolVar(&exitZero, "exit-zero", false, "always exit with status 0, even when validations fail")
+	validateCmd.Flags().Float64Var(&minPassRate, "min-pass-rate", 0, "minimum fraction of passing queries (0-1) required to succeed")
+	validateCmd.Flags().StringVar(&fragmentsPath, "fragments", "", "file or directory of shared GraphQL fragment definitions")
+	validateCmd.Flags().StringVar(&skipFile, "skip-file", "", "file listing known-failing query paths or globs, one per line")
+	validateCmd.Flags().BoolVar(&noNestedErrorScan, "no-nested-error-scan", false, "do not treat error/errors keys in response data as failures")
+	validateCmd.Flags().StringSliceVar(&nestedErrorPaths, "nested-error-paths", nil, "only scan these response paths for error keys, e.g. root,users.posts (repeatable)")
+	validateCmd.Flags().BoolVar(&requireNonEmpty, "require-non-empty", false, "fail queries whose top-level results are empty, unless an '# expect:' header says otherwise")
+	validateCmd.Flags().BoolVar(&requireDescription, "require-description", false, "fail query files without a leading description comment")
+	validateCmd.Flags().BoolVar(&checkDeprecated, "check-deprecated", false, "warn about selected columns whose database comment marks them as deprecated")
+	validateCmd.Flags().BoolVar(&strictWarnings, "strict", false, "fail queries on warnings such as deprecation notices, not just errors")
+	validateCmd.Flags().IntVar(&maxResultBytes, "max-result-bytes", 0, "fail queries whose response data exceeds this many bytes (0 = no limit)")
+	validateCmd.Flags().DurationVar(&queryBudget, "budget", 0, "fail queries that take longer than this to run, e.g. 500ms (0 = no limit)")
+	validateCmd.Flags().StringSliceVar(&validateRoles, "roles", nil, "run each query once per GraphJin role and print a role matrix, e.g. anon,user,admin")
+	validateCmd.Flags().StringVar(&setupFile, "setup", "", "SQL script to run against each database before validating")
+	validateCmd.Flags().StringVar(&teardownFile, "teardown", "", "SQL script to run against each database after validating, even when validation fails")
+	validateCmd.Flags().IntVar(&maxSQLStatements, "max-sql-statements", 0, "warn about read queries that run more than this many SQL statements, a potential N+1 (0 = no check)")
+	validateCmd.Flags().BoolVar(&showSQL, "show-sql", false, "include the SQL GraphJin generates for each query")
+	validateCmd.Flags().StringSliceVar(&includeTags, "tag", nil, "only validate queries whose '# tags:' header has this tag (repeatable)")
+	validateCmd.Flags().StringSliceVar(&excludeTags, "exclude-tag", nil, "skip queries whose '# tags:' header has this tag (repeatable)")
+	validateCmd.Flags().StringSliceVar(&allowedDirectives, "allow-directive", nil, "accept this query directive besides GraphJin's own, without the @ (repeatable)")
+	validateCmd.Flags().StringVar(&otelEndpoint, "otel-endpoint", "", "export OpenTelemetry traces of the run to this OTLP/HTTP collector, e.g. http://localhost:4318 (also honors OTEL_EXPORTER_OTLP_ENDPOINT)")
+	validateCmd.Flags().StringVar(&metricsFile, "metrics-out", "", "write Prometheus text-format metrics for the run to this file")
+	validateCmd.Flags().StringVar(&timingsFile, "timings-out", "", "write every query's duration to this file, as JSON if it ends in .json and CSV otherwise")
+	validateCmd.Flags().StringVar(&baselineFile, "baseline", "", "compare against the results saved in this file, then save this run to it")
+	validateCmd.Flags().BoolVar(&regressionsOnly, "regressions-only", false, "with --baseline, only fail on queries that newly failed")
+	validateCmd.Flags().BoolVar(&allDatabases, "all-databases", false, "validate against every database in the config's databases list")
+	validateCmd.Flags().BoolVar(&continueOnConfigError, "continue-on-config-error", false, "with --all-databases, record a database that cannot be connected to and go on with the rest, failing at the end")
+	validateCmd.Flags().StringVar(&schemaFile, "schema-file", "", "check queries against this schema snapshot (CSV export of information_schema.columns or JSON) without connecting to a database")
+	validateCmd.Flags().BoolVarP(&quiet, "quiet", "Q", false, "only print failing queries and the summary in text output")
+	validateCmd.Flags().BoolVar(&summaryByCategory, "summary-by-category", false, "count errors by category (parse, missing table/column, timeout, ...) in the summary")
+	validateCmd.Flags().BoolVar(&groupByDir, "group-by-dir", false, "group text results by directory with per-directory subtotals")
+	validateCmd.Flags().StringVarP(&outFile, "out", "o", "", "write results to this file instead of stdout; progress stays on stderr")
+	validateCmd.Flags().StringVar(&templateFile, "template", "", "render the results through this Go text/template file instead of --format")
+	validateCmd.Flags().StringVar(&outputFormat, "format", "text", "output format: text, json, ndjson, csv or sarif (-j is shorthand for json)")
+	validateCmd.MarkFlagsMutuallyExclusive("manifest", "file")
+	validateCmd.MarkFlagsMutuallyExclusive("fail-fast", "max-failures")
+	validateCmd.MarkFlagsMutuallyExclusive("scenario", "file")
+	validateCmd.MarkFlagsMutuallyExclusive("scenario", "manifest")
+	validateCmd.MarkFlagsMutuallyExclusive("scenario", "scan-go")
+	validateCmd.MarkFlagsMutuallyExclusive("scenario", "roles")
+	validateCmd.MarkFlagsMutuallyExclusive("scenario", "rerun-failed")
+	validateCmd.MarkFlagsMutuallyExclusive("scenario", "changed-only")
+	validateCmd.MarkFlagsMutuallyExclusive("scenario", "schema-file")
+	validateCmd.MarkFlagsMutuallyExclusive("scan-go", "file")
+	validateCmd.MarkFlagsMutuallyExclusive("scan-go", "manifest")
+	validateCmd.MarkFlagsMutuallyExclusive("scan-markdown", "file")
+	validateCmd.MarkFlagsMutuallyExclusive("scan-markdown", "manifest")
+	validateCmd.MarkFlagsMutuallyExclusive("scan-markdown", "scan-go")
+	validateCmd.MarkFlagsMutuallyExclusive("scenario", "scan-markdown")
+	validateCmd.MarkFlagsMutuallyExclusive("rerun-failed", "file")
+	validateCmd.MarkFlagsMutuallyExclusive("changed-only", "file")
+	validateCmd.MarkFlagsMutuallyExclusive("changed-only", "manifest")
+	validateCmd.MarkFlagsMutuallyExclusive("changed-only", "rerun-failed")
+	validateCmd.MarkFlagsMutuallyExclusive("no-nested-error-scan", "nested-error-paths")
+	validateCmd.MarkFlagsMutuallyExclusive("schema-file", "all-databases")
+	validateCmd.MarkFlagsMutuallyExclusive("schema-file", "batch")
+	validateCmd.MarkFlagsMutuallyExclusive("schema-file", "setup")
+	validateCmd.MarkFlagsMutuallyExclusive("schema-file", "teardown")
+	validateCmd.MarkFlagsMutuallyExclusive("schema-file", "check-deprecated")
 }
 
 func runValidate(cmd *cobra.Command, args []string) error {
-	// Load configuration
-	config, err := LoadConfig(cfgFile)
-	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+	if minPassRate < 0 || minPassRate > 1 {
+		return fmt.Errorf("--min-pass-rate must be between 0 and 1, got %v", minPassRate)
 	}
 
-	if err := config.Validate(); err != nil {
-		return fmt.Errorf("invalid configuration: %w", err)
+	if operationName != "" && queryFile == "" {
+		return fmt.Errorf("--operation requires --file")
 	}
 
-	// Initialize GraphJin
-	gj, db, err := initializeGraphJin(config)
-	if err != nil {
-		return fmt.Errorf("failed to initialize GraphJin: %w", err)
+	if continueOnConfigError && !allDatabases {
+		return fmt.Errorf("--continue-on-config-error requires --all-databases")
+	}
+
+	// Catch a mistyped fixture path before touching the database
+	for _, script := range []string{setupFile, teardownFile} {
+		if script == "" {
+			continue
+		}
+		if _, err := os.Stat(script); err != nil {
+			return fmt.Errorf("failed to read SQL script: %w", err)
+		}
+	}
+
+	if jsonOutput {
+		outputFormat = "json"
+	}
+	switch outputFormat {
+	case "text", "json", "ndjson", "csv", "sarif":
+	default:
+		return fmt.Errorf("invalid output format %q (expected text, json, ndjson, csv or sarif)", outputFormat)
+	}
+
+	if listOnly && outputFormat != "text" && outputFormat != "json" {
+		return fmt.Errorf("--list-only supports text and json output")
+	}
+
+	if templateFile != "" {
+		if outputFormat != "text" || listOnly {
+			return fmt.Errorf("--template cannot be combined with --format, --json or --list-only")
+		}
+		tmpl, err := loadReportTemplate(templateFile)
+		if err != nil {
+			return err
+		}
+		reportTemplate = tmpl
+	}
+
+	// Load configuration; listing the plan does not need a database
+	var config *Config
+	var targets []validationTarget
+	var err error
+	if listOnly {
+		config, err = LoadOptionalConfig(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+	} else if schemaFile != "" {
+		// Validate against the snapshot; no database connection is needed
+		config, err = LoadOptionalConfig(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		var dbSchema string
+		if config != nil {
+			dbSchema = config.Database.Schema
+		}
+		offlineSchema, err = engine.LoadSchemaFile(schemaFile, dbSchema)
+		if err != nil {
+			return err
+		}
+		logDebug("Loaded %d table(s) from schema snapshot: %s", len(offlineSchema), schemaFile)
+		targets = []validationTarget{{config: config}}
+	} else {
+		config, err = LoadConfig(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		targets, err = resolveTargets(config)
+		if err != nil {
+			return err
+		}
+	}
+
+	applyQueriesConfig(cmd, config)
+
+	// Load shared fragments
+	if fragmentsPath != "" {
+		sharedFragments, err = validationOptions().LoadFragments(fragmentsPath)
+		if err != nil {
+			return fmt.Errorf("failed to load fragments: %w", err)
+		}
+		logDebug("Loaded %d shared fragment(s) from: %s", len(sharedFragments), fragmentsPath)
+	}
+
+	// Load known failures
+	if skipFile != "" {
+		skipPatterns, err = loadSkipFile(skipFile)
+		if err != nil {
+			return fmt.Errorf("failed to load skip file: %w", err)
+		}
 	}
-	defer db.Close()
 
 	// Find query files to validate
 	var queryFiles []string
 
-	if queryFile != "" {
+	var failed map[string]bool
+	var failedPaths []string
+	if rerunFailedFile != "" {
+		failed, failedPaths, err = loadFailedPaths(rerunFailedFile)
+		if err != nil {
+			return err
+		}
+		logInfo("Re-running %d failed query(s) from %s", len(failedPaths), rerunFailedFile)
+	}
+
+	if manifestFile != "" {
+		// Validate the queries defined in the manifest
+		queryFiles, inlineQueries, err = loadManifest(manifestFile)
+		if err != nil {
+			return err
+		}
+		if failed != nil {
+			queryFiles = selectFailedFiles(queryFiles, failed)
+		}
+	} else if len(scenarioFiles) > 0 {
+		// Run the scenarios' steps in order, threading results through
+		queryFiles, inlineQueries, err = loadScenarios(scenarioFiles)
+		if err != nil {
+			return err
+		}
+	} else if scanGo {
+		// Validate the queries embedded in Go source files
+		queryFiles, inlineQueries, err = loadGoQueries(queriesDirs)
+		if err != nil {
+			return err
+		}
+		if failed != nil {
+			queryFiles = selectFailedFiles(queryFiles, failed)
+		}
+	} else if scanMarkdown {
+		// Validate the graphql code blocks of Markdown files
+		queryFiles, inlineQueries, err = loadMarkdownQueries(queriesDirs)
+		if err != nil {
+			return err
+		}
+		if failed != nil {
+			queryFiles = selectFailedFiles(queryFiles, failed)
+		}
+	} else if queryFile != "" {
 		// Validate single file
 		if _, err := os.Stat(queryFile); os.IsNotExist(err) {
 			return fmt.Errorf("query file not found: %s", queryFile)
 		}
 		queryFiles = []string{queryFile}
+	} else if failed != nil {
+		// Validate the files that failed in the previous report
+		for _, path := range failedPaths {
+			if _, err := os.Stat(path); err != nil {
+				logWarn("Skipping %s from the report: %v", path, err)
+				continue
+			}
+			queryFiles = append(queryFiles, path)
+		}
 	} else {
-		// Find all query files in directory
-		queryFiles, err = findQueryFiles(queriesDir)
+		// Find all query files in the queries directories
+		queryFiles, err = discoverQueryFiles(queriesDirs...)
+		if err != nil {
+			return err
+		}
+		queryFiles = excludeFragmentFiles(queryFiles)
+		queryFiles = excludePartialFiles(queryFiles)
+		queryFiles = selectQueryFiles(queryFiles)
+		queryFiles, err = selectTaggedFiles(queryFiles)
 		if err != nil {
-			return fmt.Errorf("failed to find query files: %w", err)
+			return err
+		}
+		if changedOnly {
+			queryFiles, err = selectChangedFiles(queryFiles)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
 	if len(queryFiles) == 0 {
-		fmt.Println("No query files found")
+		logWarn("No query files found")
 		return nil
 	}
 
-	if verbose {
-		fmt.Printf("Found %d query file(s) to validate\n\n", len(queryFiles))
+	logDebug("Found %d query file(s) to validate", len(queryFiles))
+
+	// Results go to the --out file if one was given
+	return withResultsOutput(func() error {
+		if listOnly {
+			return printPlan(planQueries(queryFiles))
+		}
+		return validateAndReport(targets, queryFiles)
+	})
+}
+
+// validateAndReport validates the query files against each target, then
+// compares, records and prints the results
+func validateAndReport(targets []validationTarget, queryFiles []string) error {
+	shutdownTracing, err := setupTracing()
+	if err != nil {
+		return err
+	}
+	defer shutdownTracing()
+
+	stop := handleInterrupts()
+	defer stop()
+
+	var results ValidationSummary
+	runSpan := startRunSpan()
+	defer func() { endRunSpan(runSpan, results) }()
+
+	// Reject mutations before connecting to any database
+	if denyMutations {
+		queryFiles, results = rejectMutations(queryFiles)
+		if results.Failed > 0 {
+			logDebug("Rejected %d query(s) containing mutations", results.Failed)
+		}
+		for _, result := range results.Results {
+			streamResult(result)
+		}
 	}
 
-	// Run validation
-	results := validateQueries(gj, queryFiles)
+	// With a mutations directory, writes elsewhere are rejected and the
+	// rest are rolled back
+	if dirs := findMutationsDirs(); len(dirs) > 0 && inlineQueries == nil {
+		var misplaced ValidationSummary
+		queryFiles, misplaced = enforceMutationsDir(queryFiles, dirs)
+		if misplaced.Failed > 0 {
+			logDebug("Rejected %d mutation(s) outside the mutations directory", misplaced.Failed)
+		}
+		for _, result := range misplaced.Results {
+			streamResult(result)
+		}
+		results = mergeSummaries(results, misplaced)
+	}
+
+	// Run validation against each target
+	for _, target := range targets {
+		if failureLimitReached(results.Failed) {
+			results.StoppedEarly = true
+			break
+		}
+		if len(queryFiles) == 0 || interrupted() {
+			break
+		}
+
+		summary, err := validateTarget(target, queryFiles, results.Failed)
+		if err != nil && continueOnConfigError {
+			// The error already names the target
+			logError("%v, moving on to the next database", err)
+			results.TargetErrors = append(results.TargetErrors, TargetError{Target: target.name, Error: err.Error()})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		results = mergeSummaries(results, summary)
+	}
+	if len(targets) > 1 {
+		results.Inconsistent = findInconsistent(results.Results)
+	}
+
+	// A partial run would make every query it did not reach look removed
+	if baselineFile != "" && results.Interrupted {
+		logWarn("Not updating the baseline %s after an interrupted run", baselineFile)
+	} else if baselineFile != "" && len(results.TargetErrors) > 0 {
+		logWarn("Not updating the baseline %s while a database could not be validated", baselineFile)
+	} else if baselineFile != "" {
+		baseline, err := loadBaseline(baselineFile)
+		if err != nil {
+			return fmt.Errorf("failed to load baseline: %w", err)
+		}
+		if baseline != nil {
+			compareBaseline(&results, baseline)
+		} else {
+			logInfo("No baseline at %s yet, it will be created", baselineFile)
+		}
+		if err := saveBaseline(baselineFile, results); err != nil {
+			return fmt.Errorf("failed to save baseline: %w", err)
+		}
+	}
+
+	if metricsFile != "" {
+		if err := writeMetricsFile(metricsFile, results); err != nil {
+			return fmt.Errorf("failed to write metrics: %w", err)
+		}
+	}
+
+	if timingsFile != "" {
+		if err := writeTimingsFile(timingsFile, results); err != nil {
+			return fmt.Errorf("failed to write timings: %w", err)
+		}
+	}
+
+	if engine.RandomUsed(validationCtx) {
+		results.Seed = randomSeed
+	}
 
 	// Print results
 	printResults(results)
+	reportSeed()
 
-	// Return error if any tests failed
-	if results.Failed > 0 {
-		return fmt.Errorf("%d validation(s) failed", results.Failed)
+	return validationExitError(results)
+}
+
+// writeMetricsFile writes the run's Prometheus metrics to path
+func writeMetricsFile(path string, summary ValidationSummary) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	if err := writeMetrics(f, summary); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeTimingsFile writes the durations of the run's queries to path, as
+// JSON when it ends in .json and CSV otherwise
+func writeTimingsFile(path string, summary ValidationSummary) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	timings := queryTimings(summary.Results)
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = writeTimingsJSON(f, timings)
+	} else {
+		err = writeTimingsCSV(f, timings)
+	}
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// validationExitError decides whether the run should fail the process,
+// honoring --exit-zero and --min-pass-rate
+func validationExitError(results ValidationSummary) error {
+	if results.Interrupted {
+		return &exitCodeError{
+			code: interruptedExitCode,
+			err:  fmt.Errorf("interrupted after validating %d query(s)", results.Total),
+		}
+	}
+
+	if len(results.TargetErrors) > 0 {
+		return fmt.Errorf("%d database(s) could not be validated", len(results.TargetErrors))
+	}
+
+	if exitZero || results.Failed == 0 {
+		return nil
+	}
+
+	if regressionsOnly && baselineFile != "" {
+		if len(results.Regressions) == 0 {
+			return nil
+		}
+		return fmt.Errorf("%d query(s) regressed since the baseline", len(results.Regressions))
+	}
+
+	if minPassRate > 0 {
+		passRate := float64(results.Passed) / float64(results.Total)
+		if passRate >= minPassRate {
+			return nil
+		}
+		return fmt.Errorf("pass rate %.1f%% is below the required %.1f%% (%d validation(s) failed)",
+			passRate*100, minPassRate*100, results.Failed)
+	}
+
+	return fmt.Errorf("%d validation(s) failed", results.Failed)
 }
 
 func initializeGraphJin(config *Config) (*graphjin.GraphJin, *sql.DB, error) {
-	// Connect to database
-	db, err := sql.Open("pgx", config.GetDSN())
+	return initializeGraphJinContext(context.Background(), config)
+}
+
+// initializeGraphJinContext is initializeGraphJin with the connection test
+// bounded by ctx
+func initializeGraphJinContext(ctx context.Context, config *Config) (*graphjin.GraphJin, *sql.DB, error) {
+	// Connect to database; writes are never committed with a mutations
+	// directory
+	var db *sql.DB
+	var err error
+	if rollbackWrites {
+		db, err = openRollbackDB(config.GetDSN())
+	} else {
+		db, err = sql.Open("pgx", config.GetDSN())
+	}
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to connect to database: %w", err)
+		return nil, nil, fmt.Errorf("failed to connect to database: %w", redactError(err))
 	}
 
 	// Test connection
-	if err := db.Ping(); err != nil {
+	if err := db.PingContext(ctx); err != nil {
 		db.Close()
-		return nil, nil, fmt.Errorf("failed to ping database: %w", err)
+		return nil, nil, fmt.Errorf("failed to ping database: %w", redactError(err))
 	}
 
-	// Create GraphJin configuration
-	gjConfig := &graphjin.Config{
-		Debug:            verbose,
-		Production:       config.Production,
-		DisableAllowList: true,
-		DefaultBlock:     false,
+	// Flags take precedence over the config file
+	gjConfig := *config
+	if rootCmd.PersistentFlags().Changed("graphjin-debug") {
+		gjConfig.GraphJinDebug = graphjinDebug
+	}
+	if rootCmd.PersistentFlags().Changed("production") {
+		gjConfig.Production = productionMode
 	}
+	queryUser.id, queryUser.role = config.GraphJin.UserID, config.GraphJin.DefaultRole
 
-	// Initialize GraphJin
-	gj, err := graphjin.NewGraphJin(gjConfig, db)
+	gj, err := engine.NewGraphJin(&gjConfig, db)
 	if err != nil {
 		db.Close()
-		return nil, nil, fmt.Errorf("failed to create GraphJin instance: %w", err)
+		return nil, nil, redactError(err)
 	}
 
+	logDebug("GraphJin warm-up took %v", engine.WarmUp(gj))
+
 	return gj, db, nil
 }
 
-func findQueryFiles(dir string) ([]string, error) {
-	var queryFiles []string
-
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		if !info.IsDir() && strings.HasSuffix(info.Name(), ".graphql") {
-			queryFiles = append(queryFiles, path)
-		}
-
-		return nil
-	})
+// validationOptions returns the engine options set by the flags, the
+// config and the target being validated
+func validationOptions() engine.Options {
+	return engine.Options{
+		Extensions:         queryExtensions,
+		QueryDirs:          append([]string{queriesDir}, queriesDirs...),
+		Env:                variablesEnv,
+		ShowSQL:            showSQL,
+		Strict:             strictWarnings,
+		NoNestedErrorScan:  noNestedErrorScan,
+		NestedErrorPaths:   nestedErrorPaths,
+		Operation:          operationName,
+		Fragments:          sharedFragments,
+		AllowedDirectives:  allowedDirectives,
+		RequireDescription: requireDescription,
+		RequireNonEmpty:    requireNonEmpty,
+		Budget:             queryBudget,
+		MaxResultBytes:     maxResultBytes,
+		MaxSQLStatements:   maxSQLStatements,
+		DefaultRole:        queryUser.role,
+		UserID:             queryUser.id,
+		Schema:             targetSchema,
+		Snapshot:           offlineSchema,
+		Secrets:            knownSecrets,
+		Tracer:             tracer,
+		Logger:             cliLogger{},
+	}
+}
 
-	return queryFiles, err
+// isQueryFile reports whether a file name has a recognized query extension
+func isQueryFile(name string) bool {
+	return validationOptions().IsQueryFile(name)
 }
 
-func validateQueries(gj *graphjin.GraphJin, queryFiles []string) ValidationSummary {
-	summary := ValidationSummary{
-		Total:   len(queryFiles),
-		Results: make([]TestResult, 0, len(queryFiles)),
-	}
+// queryBasePath strips the matched query extension from a path, giving the
+// prefix used to locate companion files such as variables
+func queryBasePath(path string) string {
+	return validationOptions().QueryBasePath(path)
+}
 
-	for _, qf := range queryFiles {
-		result := validateSingleQuery(gj, qf)
-		summary.Results = append(summary.Results, result)
+// findQueryFiles returns the query files under each of the given directories
+func findQueryFiles(dirs ...string) ([]string, error) {
+	return validationOptions().FindQueryFiles(dirs...)
+}
 
-		if result.Passed {
-			summary.Passed++
-		} else {
-			summary.Failed++
-			if failFast {
-				break
+// discoverQueryFiles finds the query files in the queries directories for
+// a command. A missing directory, or directories without any query files,
+// are errors unless --allow-missing is set, in which case missing
+// directories are skipped with a warning and no files are returned.
+func discoverQueryFiles(dirs ...string) ([]string, error) {
+	var existing []string
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+			if !allowMissing {
+				return nil, fmt.Errorf("queries directory not found: %s (pass --allow-missing to allow this)", dir)
 			}
+			logWarn("Queries directory not found: %s", dir)
+			continue
 		}
+		existing = append(existing, dir)
 	}
 
-	return summary
+	files, err := findQueryFiles(existing...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find query files: %w", err)
+	}
+	if len(files) == 0 && !allowMissing {
+		return nil, fmt.Errorf("no query files found in %s (pass --allow-missing to allow this)", strings.Join(dirs, ", "))
+	}
+	return files, nil
 }
 
-func validateSingleQuery(gj *graphjin.GraphJin, queryPath string) TestResult {
-	result := TestResult{
-		Name:   filepath.Base(queryPath),
-		Path:   queryPath,
-		Passed: false,
-		Errors: []string{},
+// applyQueriesConfig falls back to the config's validate section for the
+// queries directory when --queries was not given, and loads its patterns
+func applyQueriesConfig(cmd *cobra.Command, config *Config) {
+	if config == nil {
+		return
 	}
 
-	start := time.Now()
+	if config.Queries.Dir != "" && !cmd.Flags().Changed("queries") {
+		queriesDir = config.Queries.Dir
+		queriesDirs = []string{config.Queries.Dir}
+	}
 
-	// Read query file
-	query, err := os.ReadFile(queryPath)
-	if err != nil {
-		result.Errors = append(result.Errors, fmt.Sprintf("Failed to read query file: %v", err))
-		result.Duration = time.Since(start).Milliseconds()
-		return result
+	if len(config.Queries.Extensions) > 0 && !cmd.Flags().Changed("ext") {
+		queryExtensions = engine.NormalizeExtensions(config.Queries.Extensions)
 	}
 
-	// Look for corresponding JSON file with variables
-	jsonFile := strings.TrimSuffix(queryPath, ".graphql") + ".json"
-	var variables json.RawMessage
+	if config.Queries.MutationsDir != "" {
+		mutationsDir = filepath.Clean(config.Queries.MutationsDir)
+	}
 
-	if _, err := os.Stat(jsonFile); err == nil {
-		jsonData, err := os.ReadFile(jsonFile)
-		if err != nil {
-			result.Errors = append(result.Errors, fmt.Sprintf("Failed to read variables file: %v", err))
-			result.Duration = time.Since(start).Milliseconds()
-			return result
-		}
-		variables = json.RawMessage(jsonData)
+	allowedDirectives = append(allowedDirectives, config.Queries.AllowedDirectives...)
 
-		if verbose {
-			fmt.Printf("  Using variables from: %s\n", filepath.Base(jsonFile))
+	includePatterns = config.Queries.Include
+	excludePatterns = config.Queries.Exclude
+}
+
+// queryRelPath returns a query file's path relative to the queries
+// directory that contains it, or the path itself if none does
+func queryRelPath(path string) string {
+	for _, root := range append([]string{queriesDir}, queriesDirs...) {
+		if root == "" {
+			continue
+		}
+		rel, err := filepath.Rel(root, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return rel
 		}
-	} else {
-		variables = json.RawMessage("{}")
 	}
+	return path
+}
 
-	// Execute query
-	ctx := context.Background()
-	res, err := gj.GraphQL(ctx, string(query), variables, nil)
+// queryFileSelected reports whether a query file passes the configured
+// include and exclude patterns. Patterns match the path relative to the
+// queries directory or the file name.
+func queryFileSelected(path string) bool {
+	rel := queryRelPath(path)
 
-	result.Duration = time.Since(start).Milliseconds()
+	matches := func(patterns []string) bool {
+		for _, pattern := range patterns {
+			if ok, _ := filepath.Match(pattern, rel); ok {
+				return true
+			}
+			if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok {
+				return true
+			}
+		}
+		return false
+	}
 
-	// Check for execution errors
-	if err != nil {
-		result.Errors = append(result.Errors, fmt.Sprintf("Execution error: %v", err))
+	if len(includePatterns) > 0 && !matches(includePatterns) {
+		return false
 	}
+	return !matches(excludePatterns)
+}
 
-	// Check for GraphQL errors in the response
-	if res != nil && len(res.Errors) > 0 {
-		for _, gjErr := range res.Errors {
-			result.Errors = append(result.Errors, gjErr.Message)
+// selectQueryFiles filters query files through the include/exclude patterns
+func selectQueryFiles(queryFiles []string) []string {
+	selected := queryFiles[:0]
+	for _, qf := range queryFiles {
+		if queryFileSelected(qf) {
+			selected = append(selected, qf)
 		}
 	}
+	return selected
+}
 
-	// Check for nested errors in the response data
-	if res != nil && len(res.Data) > 0 {
-		nestedErrors := findNestedErrors(res.Data)
-		if len(nestedErrors) > 0 {
-			result.Errors = append(result.Errors, nestedErrors...)
-		}
+// excludeFragmentFiles drops shared fragment files that live inside the
+// queries directory, since they are not standalone operations
+func excludeFragmentFiles(queryFiles []string) []string {
+	if fragmentsPath == "" {
+		return queryFiles
 	}
 
-	// Query passes only if there are no errors at any level
-	if len(result.Errors) == 0 {
-		result.Passed = true
+	fragmentsRoot := filepath.Clean(fragmentsPath)
+	filtered := queryFiles[:0]
+	for _, qf := range queryFiles {
+		path := filepath.Clean(qf)
+		if path == fragmentsRoot || strings.HasPrefix(path, fragmentsRoot+string(filepath.Separator)) {
+			continue
+		}
+		filtered = append(filtered, qf)
 	}
 
-	return result
+	return filtered
 }
 
-// findNestedErrors recursively searches for error fields in the GraphQL response data
-func findNestedErrors(data json.RawMessage) []string {
-	var errors []string
+// validateQueries validates the query files against one GraphJin
+// instance, labelling each result with the target name. With --roles, each
+// file is validated once per role. priorFailures counts the failures earlier
+// in the run, for --max-failures.
+func validateQueries(gj *graphjin.GraphJin, queryFiles []string, target string, priorFailures int) ValidationSummary {
+	roles := queryRoles()
+	summary := ValidationSummary{
+		Total:   len(queryFiles) * len(roles),
+		Results: make([]TestResult, 0, len(queryFiles)*len(roles)),
+	}
 
-	if len(data) == 0 {
-		return errors
+	// Batched requests run as the default role
+	var batched map[string]TestResult
+	if batchQueries && len(validateRoles) == 0 {
+		batched = runBatches(gj, queryFiles)
 	}
 
-	var result interface{}
-	if err := json.Unmarshal(data, &result); err != nil {
-		return errors
+	var scenarios *scenarioRun
+	if scenarioSteps != nil {
+		scenarios = newScenarioRun()
 	}
 
-	collectErrors(result, &errors, "")
-	return errors
-}
+	bar := newProgress(len(queryFiles) * len(roles))
+	defer bar.Clear()
 
-// collectErrors recursively walks through the data structure looking for error indicators
-func collectErrors(data interface{}, errors *[]string, path string) {
-	switch v := data.(type) {
-	case map[string]interface{}:
-		// Check for "errors" key (array of errors)
-		if errs, ok := v["errors"]; ok && errs != nil {
-			if errArray, ok := errs.([]interface{}); ok && len(errArray) > 0 {
-				for i, e := range errArray {
-					if errMap, ok := e.(map[string]interface{}); ok {
-						if msg, ok := errMap["message"].(string); ok {
-							location := path
-							if location == "" {
-								location = "root"
-							}
-							*errors = append(*errors, fmt.Sprintf("Error at %s[%d]: %s", location, i, msg))
-						} else {
-							*errors = append(*errors, fmt.Sprintf("Error at %s[%d]: %v", path, i, e))
-						}
-					}
-				}
-			}
+	for _, qf := range queryFiles {
+		var allowed []string
+		if len(validateRoles) > 0 {
+			allowed = allowedRoles(qf)
 		}
 
-		// Check for "error" key (single error)
-		if errVal, ok := v["error"]; ok && errVal != nil {
-			switch errStr := errVal.(type) {
-			case string:
-				if errStr != "" {
-					location := path
-					if location == "" {
-						location = "root"
+		for _, role := range roles {
+			if interrupted() {
+				return interruptedSummary(summary)
+			}
+
+			bar.Start(filepath.Base(qf))
+			result, ok := batched[qf]
+			if !ok {
+				if input, ok := inlineQueries[qf]; ok {
+					if role != "" {
+						input.Role = role
 					}
-					*errors = append(*errors, fmt.Sprintf("Error at %s: %s", location, errStr))
-				}
-			case map[string]interface{}:
-				if msg, ok := errStr["message"].(string); ok {
-					location := path
-					if location == "" {
-						location = "root"
+					if step, ok := scenarioSteps[qf]; ok {
+						result = scenarios.validateStep(gj, input, step)
+					} else {
+						result = validateInputSafely(gj, input)
 					}
-					*errors = append(*errors, fmt.Sprintf("Error at %s: %s", location, msg))
+				} else {
+					result = validateQuerySafely(gj, qf, role)
 				}
 			}
-		}
-
-		// Recursively check nested objects
-		for key, value := range v {
-			newPath := key
-			if path != "" {
-				newPath = path + "." + key
+			bar.Done()
+			// A query failing after the interrupt was likely cancelled
+			if interrupted() && !result.Passed {
+				return interruptedSummary(summary)
+			}
+			result = applyAllowedRoles(result, role, allowed)
+			skipped := isSkipped(qf, skipPatterns)
+
+			switch {
+			case skipped && result.Passed:
+				result.UnexpectedPass = true
+				summary.Passed++
+				summary.UnexpectedPasses++
+			case skipped, result.Skipped:
+				result.Skipped = true
+				summary.Skipped++
+			case result.Passed:
+				summary.Passed++
+			default:
+				summary.Failed++
 			}
-			collectErrors(value, errors, newPath)
-		}
 
-	case []interface{}:
-		// Recursively check arrays
-		for i, item := range v {
-			newPath := fmt.Sprintf("%s[%d]", path, i)
-			if path == "" {
-				newPath = fmt.Sprintf("[%d]", i)
+			result.Target = target
+			summary.Warnings += len(result.Warnings)
+			summary.Results = append(summary.Results, result)
+			streamResult(result)
+
+			if failureLimitReached(priorFailures + summary.Failed) {
+				summary.StoppedEarly = true
+				return summary
 			}
-			collectErrors(item, errors, newPath)
 		}
 	}
+
+	return summary
+}
+
+// failureLimitReached reports whether failed failures should stop the run,
+// per --fail-fast or --max-failures
+func failureLimitReached(failed int) bool {
+	if failFast {
+		return failed > 0
+	}
+	return maxFailures > 0 && failed >= maxFailures
+}
+
+// interruptedSummary marks a summary cut short by a signal, counting only
+// the queries it has results for
+func interruptedSummary(summary ValidationSummary) ValidationSummary {
+	summary.Total = len(summary.Results)
+	summary.Interrupted = true
+	return summary
 }
 
+// validateQuerySafely validates a single query file as role, or GraphJin's
+// default role when role is empty, converting a panic raised during
+// validation into a failed result so the run can continue. It also
+// attaches the structured form of any errors to the result.
+func validateQuerySafely(gj *graphjin.GraphJin, queryPath, role string) TestResult {
+	return validationOptions().ValidateFile(validationCtx, gj, queryPath, role)
+}
+
+// validateInputSafely is validateQuerySafely for an in-memory query
+func validateInputSafely(gj *graphjin.GraphJin, input queryInput) TestResult {
+	return validationOptions().ValidateQuery(validationCtx, gj, input)
+}
+
+// recoverValidation runs a validation, turning a panic into a failed result
+// for the named query, and attaches the structured form of its errors with
+// secrets masked
+func recoverValidation(name, path string, validate func() TestResult) TestResult {
+	return validationOptions().Recover(name, path, validate)
+}
+
+// queryInput is a query to validate along with its variables, read from a
+// query file or defined inline in a manifest
+type queryInput = engine.QueryInput
+
 func printResults(summary ValidationSummary) {
-	if jsonOutput {
-		jsonData, _ := json.MarshalIndent(summary, "", "  ")
-		fmt.Println(string(jsonData))
+	if summaryByCategory {
+		summary.ErrorCategories = countErrorCategories(summary.Results)
+	}
+
+	if reportTemplate != nil {
+		if err := reportTemplate.Execute(resultsOutput, summary); err != nil {
+			logError("Failed to render template: %v", err)
+		}
 		return
 	}
 
-	// Text output
-	fmt.Println()
-	fmt.Println("╔══════════════════════════════════════════════════════════════╗")
-	fmt.Println("║            GraphQL Query Validation Results                  ║")
-	fmt.Println("╚══════════════════════════════════════════════════════════════╝")
-	fmt.Println()
+	switch outputFormat {
+	case "json":
+		jsonData, _ := marshalOutput(summary)
+		fmt.Fprintln(resultsOutput, string(jsonData))
+		return
+	case "ndjson":
+		if err := writeNDJSONSummary(resultsOutput, summary); err != nil {
+			logError("Failed to write summary: %v", err)
+		}
+		return
+	case "csv":
+		if err := writeResultsCSV(resultsOutput, summary); err != nil {
+			logError("Failed to write CSV: %v", err)
+		}
+		return
+	case "sarif":
+		if err := writeResultsSARIF(resultsOutput, summary); err != nil {
+			logError("Failed to write SARIF: %v", err)
+		}
+		return
+	}
 
-	for _, result := range summary.Results {
-		if result.Passed {
-			fmt.Printf("  ✓ PASS  %-40s %4dms\n", result.Name, result.Duration)
+	// Text output; box-drawing characters are only used on a terminal
+	tty := resultsTTY()
+	branch := "└─"
+	if !tty {
+		branch = "-"
+	}
+
+	// Quiet mode drops the banner and passing results
+	if !quiet {
+		fmt.Fprintln(resultsOutput)
+		if tty {
+			fmt.Fprintln(resultsOutput, "╔══════════════════════════════════════════════════════════════╗")
+			fmt.Fprintln(resultsOutput, "║            GraphQL Query Validation Results                  ║")
+			fmt.Fprintln(resultsOutput, "╚══════════════════════════════════════════════════════════════╝")
 		} else {
-			fmt.Printf("  ✗ FAIL  %-40s %4dms\n", result.Name, result.Duration)
-			for _, err := range result.Errors {
-				fmt.Printf("          └─ %s\n", err)
-			}
+			fmt.Fprintln(resultsOutput, "GraphQL Query Validation Results")
+		}
+		fmt.Fprintln(resultsOutput)
+	}
+
+	if groupByDir {
+		printGroupedResults(summary.Results, branch)
+	} else {
+		for _, result := range summary.Results {
+			printResultLine(result, branch)
+		}
+	}
+
+	if !quiet {
+		fmt.Fprintln(resultsOutput)
+		if tty {
+			fmt.Fprintln(resultsOutput, "──────────────────────────────────────────────────────────────────")
+		}
+	}
+
+	if summary.Failed == 0 && summary.Skipped == 0 && !summary.Interrupted && len(summary.TargetErrors) == 0 {
+		fmt.Fprint(resultsOutput, green(fmt.Sprintf("  ✓ All %d queries passed validation", summary.Total)))
+		if summary.Warnings > 0 {
+			fmt.Fprintf(resultsOutput, " (%d warning(s))", summary.Warnings)
+		}
+		fmt.Fprintln(resultsOutput)
+	} else {
+		fmt.Fprintf(resultsOutput, "  Summary: %d total, %s, %s",
+			summary.Total,
+			green(fmt.Sprintf("%d passed", summary.Passed)),
+			red(fmt.Sprintf("%d failed", summary.Failed)))
+		if summary.Skipped > 0 {
+			fmt.Fprintf(resultsOutput, ", %s", dim(fmt.Sprintf("%d skipped", summary.Skipped)))
+		}
+		if summary.Warnings > 0 {
+			fmt.Fprintf(resultsOutput, ", %d warning(s)", summary.Warnings)
+		}
+		fmt.Fprintln(resultsOutput)
+	}
+	if summary.Interrupted {
+		fmt.Fprintln(resultsOutput, "  Interrupted: only the queries validated before the run was stopped are included")
+	}
+	if summary.StoppedEarly {
+		fmt.Fprintf(resultsOutput, "  %s after %d failure(s), the remaining queries were not validated\n", red("Stopped early"), summary.Failed)
+	}
+	if summary.UnexpectedPasses > 0 {
+		fmt.Fprintf(resultsOutput, "  %d skipped query(s) unexpectedly passing, prune the skip file\n", summary.UnexpectedPasses)
+	}
+	if len(summary.ErrorCategories) > 0 {
+		printErrorCategories(summary.ErrorCategories)
+	}
+	if len(validateRoles) > 0 && len(summary.Results) > 0 {
+		fmt.Fprintln(resultsOutput)
+		printRoleMatrix(summary.Results)
+	}
+	if len(summary.TargetErrors) > 0 {
+		fmt.Fprintf(resultsOutput, "  %s:\n", red(fmt.Sprintf("%d database(s) could not be validated", len(summary.TargetErrors))))
+		for _, targetErr := range summary.TargetErrors {
+			fmt.Fprintf(resultsOutput, "    - %s\n", targetErr.Error)
+		}
+	}
+	if len(summary.Inconsistent) > 0 {
+		fmt.Fprintf(resultsOutput, "  %d query(s) pass on some databases but fail on others:\n", len(summary.Inconsistent))
+		for _, path := range summary.Inconsistent {
+			fmt.Fprintf(resultsOutput, "    - %s\n", path)
 		}
 	}
+	if len(summary.Regressions) > 0 {
+		fmt.Fprintf(resultsOutput, "  %s since the baseline:\n", red(fmt.Sprintf("%d regression(s)", len(summary.Regressions))))
+		for _, key := range summary.Regressions {
+			fmt.Fprintf(resultsOutput, "    - %s\n", key)
+		}
+	}
+	if len(summary.Fixes) > 0 {
+		fmt.Fprintf(resultsOutput, "  %s since the baseline:\n", green(fmt.Sprintf("%d fix(es)", len(summary.Fixes))))
+		for _, key := range summary.Fixes {
+			fmt.Fprintf(resultsOutput, "    - %s\n", key)
+		}
+	}
+	fmt.Fprintln(resultsOutput)
+}
 
-	fmt.Println()
-	fmt.Println("──────────────────────────────────────────────────────────────────")
+// printErrorCategories prints the --summary-by-category counts, most
+// frequent first
+func printErrorCategories(counts map[string]int) {
+	labels := make([]string, 0, len(counts))
+	for label := range counts {
+		labels = append(labels, label)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if counts[labels[i]] != counts[labels[j]] {
+			return counts[labels[i]] > counts[labels[j]]
+		}
+		return labels[i] < labels[j]
+	})
+
+	fmt.Fprintln(resultsOutput, "  Errors by category:")
+	for _, label := range labels {
+		fmt.Fprintf(resultsOutput, "    %4d  %s\n", counts[label], label)
+	}
+}
 
-	if summary.Failed == 0 {
-		fmt.Printf("  ✓ All %d queries passed validation\n", summary.Total)
+// resultShown reports whether a result is printed in text output; quiet
+// mode only shows failures and unexpected passes
+func resultShown(result TestResult) bool {
+	if !quiet {
+		return true
+	}
+	return result.UnexpectedPass || (!result.Passed && !result.Skipped)
+}
+
+// printResultLine prints the text output for a single result
+func printResultLine(result TestResult, branch string) {
+	if !resultShown(result) {
+		return
+	}
+
+	duration := dim(fmt.Sprintf("%4dms", result.Duration))
+	if verbose && !result.Skipped {
+		duration = dim(fmt.Sprintf("%4dms (compile %dms, execute %dms)", result.Duration, result.CompileDuration, result.ExecuteDuration))
+	}
+	if result.Target != "" {
+		result.Name = fmt.Sprintf("[%s] %s", result.Target, result.Name)
+	}
+	if result.Denied {
+		result.Name = fmt.Sprintf("%s (%s, denied)", result.Name, result.Role)
+	} else if result.Role != "" {
+		result.Name = fmt.Sprintf("%s (%s)", result.Name, result.Role)
+	}
+	if result.UnexpectedPass {
+		fmt.Fprintf(resultsOutput, "  %s  %-40s %s\n", green("✓ PASS"), result.Name, duration)
+		fmt.Fprintf(resultsOutput, "          %s unexpectedly passing, remove it from the skip file\n", branch)
+	} else if result.Passed {
+		fmt.Fprintf(resultsOutput, "  %s  %-40s %s\n", green("✓ PASS"), result.Name, duration)
+	} else if result.Skipped {
+		fmt.Fprintf(resultsOutput, "  %s  %-40s %s\n", dim("○ SKIP"), result.Name, duration)
+		for _, err := range result.Errors {
+			fmt.Fprintf(resultsOutput, "          %s %s\n", branch, dim(err))
+		}
 	} else {
-		fmt.Printf("  Summary: %d total, %d passed, %d failed\n",
-			summary.Total, summary.Passed, summary.Failed)
+		fmt.Fprintf(resultsOutput, "  %s  %-40s %s\n", red("✗ FAIL"), result.Name, duration)
+		for _, err := range result.Errors {
+			fmt.Fprintf(resultsOutput, "          %s %s\n", branch, err)
+		}
+	}
+
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(resultsOutput, "          %s warning: %s\n", branch, warning)
+	}
+
+	if result.SQL != "" {
+		for _, line := range strings.Split(result.SQL, "\n") {
+			fmt.Fprintf(resultsOutput, "          %s\n", dim(line))
+		}
+	}
+}
+
+// printGroupedResults prints results under a header for each parent
+// directory, with per-directory subtotals
+func printGroupedResults(results []TestResult, branch string) {
+	var dirs []string
+	groups := make(map[string][]TestResult)
+	for _, result := range results {
+		dir := filepath.Dir(result.Path)
+		if _, ok := groups[dir]; !ok {
+			dirs = append(dirs, dir)
+		}
+		groups[dir] = append(groups[dir], result)
+	}
+	sort.Strings(dirs)
+
+	printed := 0
+	for _, dir := range dirs {
+		var passed, failed, shown int
+		for _, result := range groups[dir] {
+			switch {
+			case result.Passed:
+				passed++
+			case !result.Skipped:
+				failed++
+			}
+			if resultShown(result) {
+				shown++
+			}
+		}
+		if shown == 0 {
+			continue
+		}
+
+		if printed > 0 {
+			fmt.Fprintln(resultsOutput)
+		}
+		printed++
+		subtotal := green(fmt.Sprintf("%d passed", passed))
+		if failed > 0 {
+			subtotal += ", " + red(fmt.Sprintf("%d failed", failed))
+		}
+		fmt.Fprintf(resultsOutput, "  %s/  (%s)\n", dir, subtotal)
+
+		for _, result := range groups[dir] {
+			printResultLine(result, branch)
+		}
 	}
-	fmt.Println()
 }
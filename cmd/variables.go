@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// variablesEnv selects the foo.<env>.json overrides merged over each
+// query's variables
+var variablesEnv string
+
+// findVariablesFile returns the companion variables file of a query, or an
+// empty string if there is none. A .json file is preferred over a
+// .vars.yaml file.
+func findVariablesFile(queryPath string) string {
+	return validationOptions().FindVariablesFile(queryPath)
+}
+
+// loadVariables reads the variables of a query file, with the --env
+// override merged over them. It returns the expanded variables as JSON and
+// the path they were loaded from.
+func loadVariables(ctx context.Context, queryPath string) (json.RawMessage, string, error) {
+	return validationOptions().LoadVariables(ctx, queryPath)
+}
+
+// validateVariablesSchema checks variables against the JSON Schema in the
+// query's companion .schema.json file, if one exists
+func validateVariablesSchema(queryPath string, variables json.RawMessage) ([]string, error) {
+	return validationOptions().ValidateVariablesSchema(queryPath, variables)
+}
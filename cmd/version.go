@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/spf13/cobra"
+)
+
+// graphjinModule is the module path GraphJin's version is reported for
+const graphjinModule = "github.com/dosco/graphjin"
+
+// VersionInfo describes the build, as printed by the version command
+type VersionInfo struct {
+	Version         string `json:"version"`
+	BuildDate       string `json:"build_date"`
+	Commit          string `json:"commit,omitempty"`
+	GoVersion       string `json:"go_version"`
+	Platform        string `json:"platform"`
+	GraphJinVersion string `json:"graphjin_version"`
+}
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Show version, build and dependency information",
+	Long: `Show the tool version along with the build date, Go version and the
+GraphJin release it was built with. Include this output when filing bugs.
+
+Examples:
+  # Show version information
+  gql-validate version
+
+  # Output as JSON
+  gql-validate version -j`,
+	Args: cobra.NoArgs,
+	RunE: runVersion,
+}
+
+func init() {
+	rootCmd.AddCommand(versionCmd)
+}
+
+func runVersion(cmd *cobra.Command, args []string) error {
+	info := buildVersionInfo()
+
+	if jsonOutput {
+		jsonData, err := marshalOutput(info)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(jsonData))
+		return nil
+	}
+
+	fmt.Printf("gql-validate %s\n", info.Version)
+	fmt.Printf("  Build date: %s\n", info.BuildDate)
+	if info.Commit != "" {
+		fmt.Printf("  Commit:     %s\n", info.Commit)
+	}
+	fmt.Printf("  Go:         %s (%s)\n", info.GoVersion, info.Platform)
+	fmt.Printf("  GraphJin:   %s\n", info.GraphJinVersion)
+	return nil
+}
+
+// buildVersionInfo collects the version details, reading the commit and
+// GraphJin version from the binary's embedded build info
+func buildVersionInfo() VersionInfo {
+	info := VersionInfo{
+		Version:         Version,
+		BuildDate:       BuildDate,
+		GoVersion:       runtime.Version(),
+		Platform:        runtime.GOOS + "/" + runtime.GOARCH,
+		GraphJinVersion: "unknown",
+	}
+
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+
+	for _, dep := range build.Deps {
+		if dep.Path != graphjinModule {
+			continue
+		}
+		info.GraphJinVersion = dep.Version
+		if dep.Replace != nil {
+			info.GraphJinVersion = fmt.Sprintf("%s (replaced by %s %s)", dep.Version, dep.Replace.Path, dep.Replace.Version)
+		}
+	}
+
+	for _, setting := range build.Settings {
+		if setting.Key == "vcs.revision" {
+			info.Commit = setting.Value
+		}
+	}
+
+	return info
+}
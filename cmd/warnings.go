@@ -0,0 +1,10 @@
+package cmd
+
+// strictWarnings fails queries on warnings as well as errors
+var strictWarnings bool
+
+// splitWarnings separates warnings from errors in response messages. With
+// --strict every message is an error.
+func splitWarnings(messages []string) (errs, warnings []string) {
+	return validationOptions().SplitWarnings(messages)
+}
@@ -0,0 +1,388 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Config is the gql-validate configuration, as read from config.yaml
+type Config struct {
+	Database   DatabaseConfig `yaml:"database"`
+	Production bool           `yaml:"production"`
+
+	// GraphJinDebug enables GraphJin's own debug logging
+	GraphJinDebug bool `yaml:"graphjin_debug"`
+
+	// Databases lists named targets used with --all-databases
+	Databases []DatabaseTarget `yaml:"databases"`
+
+	// Queries holds project settings for locating query files
+	Queries QueriesConfig `yaml:"validate"`
+
+	// GraphJin holds settings passed through to GraphJin
+	GraphJin GraphJinConfig `yaml:"graphjin"`
+}
+
+// DatabaseConfig holds the connection settings for a single database
+type DatabaseConfig struct {
+	// URL is a full connection string, used as-is instead of the
+	// discrete fields below
+	URL string `yaml:"url"`
+
+	Type     string `yaml:"type"`
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	DBName   string `yaml:"dbname"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	SSLMode  string `yaml:"sslmode"`
+
+	// Schema is the PostgreSQL schema to use, set as the connection's
+	// search_path; it defaults to public
+	Schema string `yaml:"schema"`
+
+	// Client certificate, key and CA bundle for mutual TLS
+	SSLCert     string `yaml:"sslcert"`
+	SSLKey      string `yaml:"sslkey"`
+	SSLRootCert string `yaml:"sslrootcert"`
+
+	// PasswordFile and PasswordCommand resolve the password from a
+	// secrets file or a command's output instead of storing it inline
+	PasswordFile    string `yaml:"password_file"`
+	PasswordCommand string `yaml:"password_command"`
+
+	// Params are extra connection parameters, such as connect_timeout or
+	// application_name, passed to the driver as-is
+	Params map[string]string `yaml:"params"`
+}
+
+// DatabaseTarget is a named database in the databases list
+type DatabaseTarget struct {
+	Name           string `yaml:"name"`
+	DatabaseConfig `yaml:",inline"`
+}
+
+// QueriesConfig configures where query files are found. Command-line flags
+// take precedence over these values.
+type QueriesConfig struct {
+	Dir        string   `yaml:"queries_dir"`
+	Include    []string `yaml:"include"`
+	Exclude    []string `yaml:"exclude"`
+	Extensions []string `yaml:"extensions"`
+
+	// MutationsDir names the directory under the queries directory that
+	// holds mutations; it defaults to mutations
+	MutationsDir string `yaml:"mutations_dir"`
+
+	// AllowedDirectives are query directives accepted besides GraphJin's own
+	AllowedDirectives []string `yaml:"allowed_directives"`
+}
+
+// LoadConfig reads and parses a config file, with environment variable
+// overrides
+func LoadConfig(configPath string) (*Config, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not read config file: %w", err)
+	}
+	return ParseConfigData(data)
+}
+
+// ParseConfig parses a config read from r, with environment variable overrides
+func ParseConfig(r io.Reader) (*Config, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not read config: %w", err)
+	}
+	return ParseConfigData(data)
+}
+
+// ParseConfigData parses raw config YAML and applies the environment overrides
+func ParseConfigData(data []byte) (*Config, error) {
+	var config Config
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return nil, fmt.Errorf("could not parse config file: %w", yamlConfigError(err))
+	}
+
+	// Resolve the password from a secrets file or command, if configured
+	if err := config.resolvePassword(); err != nil {
+		return nil, err
+	}
+
+	// Override with environment variables if set. The DB_* variables take
+	// precedence over the standard libpq PG* variables, which in turn take
+	// precedence over the config file.
+	config.Database.URL = getEnv("DB_URL", config.Database.URL)
+	config.Database.Host = getEnv("DB_HOST", getEnv("PGHOST", config.Database.Host))
+	config.Database.DBName = getEnv("DB_NAME", getEnv("PGDATABASE", config.Database.DBName))
+	config.Database.User = getEnv("DB_USER", getEnv("PGUSER", config.Database.User))
+	config.Database.Password = getEnv("DB_PASSWORD", getEnv("PGPASSWORD", config.Database.Password))
+	config.Database.SSLMode = getEnv("DB_SSLMODE", getEnv("PGSSLMODE", config.Database.SSLMode))
+
+	// Also check for DB_PORT or PGPORT as environment variables
+	if portStr := getEnv("DB_PORT", os.Getenv("PGPORT")); portStr != "" {
+		var port int
+		if _, err := fmt.Sscanf(portStr, "%d", &port); err == nil {
+			config.Database.Port = port
+		}
+	}
+
+	return &config, nil
+}
+
+// resolvePassword replaces the inline password with the trimmed contents of
+// password_file or the trimmed output of password_command
+func (c *Config) resolvePassword() error {
+	db := &c.Database
+
+	if db.PasswordFile != "" && db.PasswordCommand != "" {
+		return fmt.Errorf("only one of password_file and password_command may be set")
+	}
+
+	if db.PasswordFile != "" {
+		data, err := os.ReadFile(db.PasswordFile)
+		if err != nil {
+			return fmt.Errorf("could not read password file: %w", err)
+		}
+		db.Password = strings.TrimSpace(string(data))
+	}
+
+	if db.PasswordCommand != "" {
+		out, err := exec.Command("sh", "-c", db.PasswordCommand).Output()
+		if err != nil {
+			return fmt.Errorf("password command failed: %w", err)
+		}
+		db.Password = strings.TrimSpace(string(out))
+	}
+
+	return nil
+}
+
+// ForTarget returns a copy of the config that connects to the given target,
+// with the target's password resolved
+func (c *Config) ForTarget(target DatabaseTarget) (*Config, error) {
+	targetConfig := *c
+	targetConfig.Database = target.DatabaseConfig
+
+	if err := targetConfig.resolvePassword(); err != nil {
+		return nil, fmt.Errorf("target %s: %w", target.Name, err)
+	}
+
+	return &targetConfig, nil
+}
+
+// getEnv returns environment variable value or default if not set
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// GetDSN returns the PostgreSQL connection string, which is the configured
+// URL when one is set
+func (c *Config) GetDSN() string {
+	if c.Database.URL != "" {
+		return c.urlWithSchema()
+	}
+
+	dsn := fmt.Sprintf("host=%s port=%d dbname=%s user=%s password=%s sslmode=%s",
+		c.Database.Host,
+		c.Database.Port,
+		dsnValue(c.Database.DBName),
+		dsnValue(c.Database.User),
+		dsnValue(c.Database.Password),
+		c.Database.SSLMode,
+	)
+
+	if c.Database.SSLCert != "" {
+		dsn += " sslcert=" + dsnValue(c.Database.SSLCert)
+	}
+	if c.Database.SSLKey != "" {
+		dsn += " sslkey=" + dsnValue(c.Database.SSLKey)
+	}
+	if c.Database.SSLRootCert != "" {
+		dsn += " sslrootcert=" + dsnValue(c.Database.SSLRootCert)
+	}
+	if c.Database.Schema != "" {
+		dsn += " search_path=" + dsnValue(c.Database.Schema)
+	}
+	for _, key := range sortedKeys(c.Database.Params) {
+		dsn += " " + key + "=" + dsnValue(c.Database.Params[key])
+	}
+
+	return dsn
+}
+
+// dsnValue quotes a key=value DSN value when it is empty or contains
+// spaces, quotes or backslashes
+func dsnValue(v string) string {
+	if v != "" && !strings.ContainsAny(v, ` '\`) {
+		return v
+	}
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `'`, `\'`)
+	return "'" + v + "'"
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// urlWithSchema returns the connection URL with the configured schema added
+// as its search_path and the extra params added to its query, unless the
+// URL already sets them
+func (c *Config) urlWithSchema() string {
+	addSchema := c.Database.Schema != "" && c.Database.Schema != "public"
+	if !addSchema && len(c.Database.Params) == 0 {
+		return c.Database.URL
+	}
+
+	u, err := url.Parse(c.Database.URL)
+	if err != nil || u.Scheme == "" {
+		return c.Database.URL
+	}
+
+	q := u.Query()
+	if addSchema && q.Get("search_path") == "" {
+		q.Set("search_path", c.Database.Schema)
+	}
+	for key, value := range c.Database.Params {
+		if !q.Has(key) {
+			q.Set(key, value)
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// Validate checks if the configuration has all required fields
+func (c *Config) Validate() error {
+	if c.Database.Schema == "" {
+		c.Database.Schema = "public"
+	}
+
+	var problems configError
+	if t := c.Database.Type; t != "" {
+		if _, ok := sslModes[t]; !ok {
+			problems.add("database type %q is not supported (expected mysql or postgres)", t)
+		}
+	}
+
+	// A connection URL replaces the discrete fields
+	if c.Database.URL != "" {
+		return problems.err()
+	}
+
+	if c.Database.Host == "" {
+		problems.add("database host is required")
+	}
+	if c.Database.Port == 0 {
+		problems.add("database port is required")
+	} else if c.Database.Port < 1 || c.Database.Port > 65535 {
+		problems.add("database port must be between 1 and 65535, got %d", c.Database.Port)
+	}
+	if c.Database.DBName == "" {
+		problems.add("database name is required")
+	}
+	if c.Database.User == "" {
+		problems.add("database user is required")
+	}
+	if modes, ok := sslModes[c.Database.Type]; ok && c.Database.SSLMode != "" && !slices.Contains(modes, c.Database.SSLMode) {
+		problems.add("database sslmode %q is not valid (expected one of %s)", c.Database.SSLMode, strings.Join(modes, ", "))
+	}
+
+	// TLS files must exist when configured
+	tlsFiles := []struct {
+		name string
+		path string
+	}{
+		{"sslcert", c.Database.SSLCert},
+		{"sslkey", c.Database.SSLKey},
+		{"sslrootcert", c.Database.SSLRootCert},
+	}
+	for _, f := range tlsFiles {
+		if f.path == "" {
+			continue
+		}
+		if _, err := os.Stat(f.path); err != nil {
+			problems.add("database %s file not found: %s", f.name, f.path)
+		}
+	}
+	if (c.Database.SSLCert == "") != (c.Database.SSLKey == "") {
+		problems.add("database sslcert and sslkey must be set together")
+	}
+
+	return problems.err()
+}
+
+// sslModes lists each database type's sslmode values in order of
+// strictness; an empty type is PostgreSQL
+var sslModes = map[string][]string{
+	"":         {"disable", "allow", "prefer", "require", "verify-ca", "verify-full"},
+	"postgres": {"disable", "allow", "prefer", "require", "verify-ca", "verify-full"},
+	"mysql":    {"disabled", "preferred", "required", "verify_ca", "verify_identity"},
+}
+
+// configError reports every problem found in a config at once
+type configError []string
+
+func (e *configError) add(format string, args ...any) {
+	*e = append(*e, fmt.Sprintf(format, args...))
+}
+
+// err returns the problems as an error, or nil when there are none
+func (e configError) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e configError) Error() string {
+	if len(e) == 1 {
+		return e[0]
+	}
+	return fmt.Sprintf("%d problems:\n  - %s", len(e), strings.Join(e, "\n  - "))
+}
+
+var (
+	yamlUnknownFieldPattern = regexp.MustCompile(`^(line \d+): field (\S+) not found in type .*$`)
+	yamlWrongTypePattern    = regexp.MustCompile(`^(line \d+): cannot unmarshal !!\w+ (.*) into (\S+)$`)
+)
+
+// yamlConfigError rewrites the YAML decoder's type errors, such as keys
+// that do not exist in the config, into a configError
+func yamlConfigError(err error) error {
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		return err
+	}
+
+	var problems configError
+	for _, msg := range typeErr.Errors {
+		if m := yamlUnknownFieldPattern.FindStringSubmatch(msg); m != nil {
+			problems.add("%s: unknown key %q", m[1], m[2])
+		} else if m := yamlWrongTypePattern.FindStringSubmatch(msg); m != nil {
+			problems.add("%s: %s is not a valid %s", m[1], m[2], m[3])
+		} else {
+			problems.add("%s", msg)
+		}
+	}
+	return problems
+}
@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/chirino/graphql/schema"
+)
+
+// graphjinDirectives are the query directives GraphJin compiles
+var graphjinDirectives = []string{
+	// Operation directives
+	"cacheControl", "script", "constraint", "validate", "validation",
+	// Field and selector directives
+	"skip", "include", "schema", "notRelated", "not_related", "through", "object",
+}
+
+// UnknownDirectiveError returns the position and name of the first
+// directive in query that is neither one of GraphJin's nor allowed, as
+// "<path>:<line>:<column>: unknown directive @name", or "" if there is none.
+// The position is mapped through source like PositionedSyntaxError's.
+func (o Options) UnknownDirectiveError(path, query string, source SourceMap) string {
+	doc, err := ParseDocument(query)
+	if err != nil {
+		return ""
+	}
+
+	var unknown *schema.Directive
+	check := func(dirs schema.DirectiveList) {
+		for _, d := range dirs {
+			if unknown == nil && !slices.Contains(graphjinDirectives, d.Name) && !slices.Contains(o.AllowedDirectives, d.Name) {
+				unknown = d
+			}
+		}
+	}
+
+	var walk func(sels schema.SelectionList)
+	walk = func(sels schema.SelectionList) {
+		for _, sel := range sels {
+			switch s := sel.(type) {
+			case *schema.FieldSelection:
+				check(s.Directives)
+				walk(s.Selections)
+			case *schema.InlineFragment:
+				check(s.Directives)
+				walk(s.Selections)
+			case *schema.FragmentSpread:
+				check(s.Directives)
+			}
+		}
+	}
+
+	for _, op := range doc.Operations {
+		check(op.Directives)
+		walk(op.Selections)
+	}
+	for _, frag := range doc.Fragments {
+		check(frag.Directives)
+		walk(frag.Selections)
+	}
+
+	if unknown == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s: unknown directive @%s", source.Position(path, unknown.NameLoc.Line, unknown.NameLoc.Column), unknown.Name)
+}
@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"unicode/utf8"
+)
+
+// byteOrderMarks maps the byte order marks of encodings other than UTF-8 to
+// their names; UTF-32 comes first as its little-endian mark starts with
+// UTF-16's
+var byteOrderMarks = []struct {
+	bom      []byte
+	encoding string
+}{
+	{[]byte{0xFF, 0xFE, 0x00, 0x00}, "UTF-32LE"},
+	{[]byte{0x00, 0x00, 0xFE, 0xFF}, "UTF-32BE"},
+	{[]byte{0xFF, 0xFE}, "UTF-16LE"},
+	{[]byte{0xFE, 0xFF}, "UTF-16BE"},
+}
+
+// UTF8BOM is the byte order mark dropped from UTF-8 files
+var UTF8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// ReadQuerySource reads a GraphQL source file as UTF-8, dropping a UTF-8
+// byte order mark. Files in any other encoding are rejected with the
+// detected encoding rather than failing later with a confusing parse error.
+func ReadQuerySource(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return decodeQuerySource(data)
+}
+
+// decodeQuerySource checks that data is UTF-8 text, without its byte order
+// mark
+func decodeQuerySource(data []byte) (string, error) {
+	for _, m := range byteOrderMarks {
+		if bytes.HasPrefix(data, m.bom) {
+			return "", notUTF8Error(m.encoding + " with byte order mark")
+		}
+	}
+	data = bytes.TrimPrefix(data, UTF8BOM)
+
+	// NUL is valid UTF-8 but never appears in GraphQL text
+	if !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+		return "", notUTF8Error(guessEncoding(data))
+	}
+	return string(data), nil
+}
+
+// guessEncoding names the likely encoding of data that is not UTF-8. ASCII
+// text in UTF-16 has a zero byte in every other position.
+func guessEncoding(data []byte) string {
+	var evenZeros, oddZeros int
+	for i, b := range data {
+		if b != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			evenZeros++
+		} else {
+			oddZeros++
+		}
+	}
+
+	half := len(data) / 4
+	switch {
+	case oddZeros > half && evenZeros == 0:
+		return "UTF-16LE"
+	case evenZeros > half && oddZeros == 0:
+		return "UTF-16BE"
+	default:
+		return "a single-byte encoding such as Windows-1252"
+	}
+}
+
+func notUTF8Error(encoding string) error {
+	return fmt.Errorf("file is not UTF-8 encoded (detected %s), re-save it as UTF-8", encoding)
+}
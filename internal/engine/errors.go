@@ -0,0 +1,238 @@
+package engine
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Error codes attached to structured validation errors
+const (
+	CodeReadError       = "READ_ERROR"
+	CodeVariablesError  = "VARIABLES_ERROR"
+	CodeSchemaViolation = "SCHEMA_VIOLATION"
+	CodeParseError      = "PARSE_ERROR"
+	CodeMissingTable    = "MISSING_TABLE"
+	CodeMissingColumn   = "MISSING_COLUMN"
+	CodeMissingRelation = "MISSING_RELATIONSHIP"
+	CodeTypeMismatch    = "TYPE_MISMATCH"
+	CodeTimeout         = "TIMEOUT"
+	CodeNestedError     = "NESTED_ERROR"
+	CodeAssertionFailed = "ASSERTION_FAILED"
+	CodePanic           = "PANIC"
+	CodeMutationDenied  = "MUTATION_DENIED"
+	CodeMissingDesc     = "MISSING_DESCRIPTION"
+	CodeNoOperation     = "OPERATION_NOT_SELECTED"
+	CodeResultTooLarge  = "RESULT_TOO_LARGE"
+	CodeRoleNotAllowed  = "ROLE_NOT_ALLOWED"
+	CodeUnknownDir      = "UNKNOWN_DIRECTIVE"
+	CodeExecutionError  = "EXECUTION_ERROR"
+)
+
+// ResultError is a machine-readable form of a validation error
+type ResultError struct {
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+	Path     string `json:"path,omitempty"`
+	Location string `json:"location,omitempty"`
+	// Operation names the operation an error belongs to when a document's
+	// operations are validated one by one
+	Operation string `json:"operation,omitempty"`
+	// Count is how many times the error occurred, when it repeated at
+	// several response paths
+	Count int `json:"count,omitempty"`
+}
+
+var (
+	// operationError captures the operation and message of errors from a
+	// document whose operations are validated one by one
+	operationError = regexp.MustCompile(`^\[([A-Za-z_][A-Za-z0-9_]*)\] (.*)$`)
+
+	// repeatedError captures the message and count of errors collapsed by
+	// DedupeErrors
+	repeatedError = regexp.MustCompile(`^(.*) \(×(\d+)\)$`)
+
+	// nestedErrorPath captures the response path and message of nested errors
+	nestedErrorPath = regexp.MustCompile(`^Error at ([^:]+): (.*)$`)
+
+	// positionedError captures the position and message of syntax errors
+	// reported as "<path>:<line>:<column>: <message>"
+	positionedError = regexp.MustCompile(`^.+:(\d+):(\d+): (.*)$`)
+
+	timeoutPattern    = regexp.MustCompile(`(?i)context deadline exceeded|timed out|timeout|canceling statement`)
+	parseErrorPattern = regexp.MustCompile(`(?i)syntax error|unexpected|expecting|unterminated|invalid character`)
+)
+
+// Schema compatibility categories
+const (
+	CategoryMissingTable  = "missing_table"
+	CategoryMissingColumn = "missing_column"
+	CategoryTypeMismatch  = "type_mismatch"
+	CategoryOther         = "other"
+)
+
+// compatPatterns maps GraphJin and PostgreSQL error text onto categories
+var compatPatterns = []struct {
+	category string
+	pattern  *regexp.Regexp
+}{
+	{CategoryMissingTable, regexp.MustCompile(`(?i)table not found|table: '[^']*' not found|relation "[^"]*" does not exist`)},
+	{CategoryMissingColumn, regexp.MustCompile(`(?i)column not found|column: '[^']*' not found|column "[^"]*" (of relation "[^"]*" )?does not exist`)},
+	{CategoryTypeMismatch, regexp.MustCompile(`(?i)must be a|invalid input syntax for type|operator does not exist|cannot be cast|type mismatch|is of type`)},
+}
+
+// ClassifyError maps an error message onto a compatibility category
+func ClassifyError(msg string) string {
+	for _, p := range compatPatterns {
+		if p.pattern.MatchString(msg) {
+			return p.category
+		}
+	}
+	return CategoryOther
+}
+
+// categoryCodes maps compat categories onto error codes
+var categoryCodes = map[string]string{
+	CategoryMissingTable:  CodeMissingTable,
+	CategoryMissingColumn: CodeMissingColumn,
+	CategoryTypeMismatch:  CodeTypeMismatch,
+}
+
+// errorCategoryLabels names the buckets error codes are counted in by
+// category; codes not listed here are counted as "other"
+var errorCategoryLabels = map[string]string{
+	CodeParseError:      "parse",
+	CodeUnknownDir:      "parse",
+	CodeMissingTable:    "missing table",
+	CodeMissingColumn:   "missing column",
+	CodeMissingRelation: "missing relationship",
+	CodeTypeMismatch:    "type mismatch",
+	CodeTimeout:         "timeout",
+	CodeAssertionFailed: "assertion",
+	CodeNestedError:     "nested data error",
+	CodeVariablesError:  "variables",
+	CodeSchemaViolation: "variables",
+}
+
+// ErrorCategoryLabel returns the category bucket of an error code
+func ErrorCategoryLabel(code string) string {
+	if label, ok := errorCategoryLabels[code]; ok {
+		return label
+	}
+	return "other"
+}
+
+// ErrorCategories returns the distinct category labels of errs, sorted
+func ErrorCategories(errs []string) []string {
+	seen := make(map[string]bool)
+	var labels []string
+	for _, detail := range StructuredErrors(errs) {
+		label := ErrorCategoryLabel(detail.Code)
+		if !seen[label] {
+			seen[label] = true
+			labels = append(labels, label)
+		}
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+// StructuredErrors converts human-readable error strings into coded errors
+func StructuredErrors(errs []string) []ResultError {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	structured := make([]ResultError, 0, len(errs))
+	for _, msg := range errs {
+		structured = append(structured, toResultError(msg))
+	}
+	return structured
+}
+
+// toResultError classifies a single error message
+func toResultError(msg string) ResultError {
+	if m := operationError.FindStringSubmatch(msg); m != nil {
+		re := toResultError(m[2])
+		re.Operation = m[1]
+		return re
+	}
+	if m := repeatedError.FindStringSubmatch(msg); m != nil {
+		re := toResultError(m[1])
+		re.Count, _ = strconv.Atoi(m[2])
+		return re
+	}
+
+	re := ResultError{Code: CodeExecutionError, Message: msg}
+
+	switch {
+	case strings.HasPrefix(msg, "Failed to read query file"):
+		re.Code = CodeReadError
+		return re
+	case strings.HasPrefix(msg, "Failed to load variables"), strings.HasPrefix(msg, "Failed to validate variables"):
+		re.Code = CodeVariablesError
+		return re
+	case strings.HasPrefix(msg, "Variables schema violation"):
+		re.Code = CodeSchemaViolation
+		return re
+	case strings.HasPrefix(msg, "Panic during validation"):
+		re.Code = CodePanic
+		return re
+	case strings.HasPrefix(msg, "Assertion failed"):
+		re.Code = CodeAssertionFailed
+		return re
+	case strings.HasPrefix(msg, "Mutation not allowed"):
+		re.Code = CodeMutationDenied
+		return re
+	case strings.HasPrefix(msg, "Missing description"):
+		re.Code = CodeMissingDesc
+		return re
+	case strings.HasPrefix(msg, "Operation not selected"):
+		re.Code = CodeNoOperation
+		return re
+	case strings.HasPrefix(msg, "Relationship"):
+		re.Code = CodeMissingRelation
+		return re
+	case strings.HasPrefix(msg, "Role not allowed"):
+		re.Code = CodeRoleNotAllowed
+		return re
+	case strings.HasPrefix(msg, "Result too large"):
+		re.Code = CodeResultTooLarge
+		return re
+	case strings.HasPrefix(msg, "Budget exceeded"):
+		re.Code = CodeTimeout
+		return re
+	}
+
+	if m := positionedError.FindStringSubmatch(msg); m != nil {
+		re.Code = CodeParseError
+		if strings.HasPrefix(m[3], "unknown directive") {
+			re.Code = CodeUnknownDir
+		}
+		re.Location = m[1] + ":" + m[2]
+		re.Message = m[3]
+		return re
+	}
+
+	if m := nestedErrorPath.FindStringSubmatch(msg); m != nil {
+		re.Code = CodeNestedError
+		re.Path = m[1]
+		re.Message = m[2]
+		return re
+	}
+
+	if code, ok := categoryCodes[ClassifyError(msg)]; ok {
+		re.Code = code
+		return re
+	}
+
+	switch {
+	case timeoutPattern.MatchString(msg):
+		re.Code = CodeTimeout
+	case parseErrorPattern.MatchString(msg):
+		re.Code = CodeParseError
+	}
+
+	return re
+}
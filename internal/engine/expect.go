@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Result expectations declared with "# expect:" headers
+const (
+	ExpectNonEmpty = "non-empty"
+	ExpectEmpty    = "empty"
+)
+
+// CheckExpectation asserts that the top-level fields of a response are all
+// empty or all non-empty, returning one error per field that is not. A
+// field is empty when it is null or an empty list.
+func CheckExpectation(expectation string, data json.RawMessage) ([]string, error) {
+	switch expectation {
+	case ExpectNonEmpty, ExpectEmpty:
+	default:
+		return nil, fmt.Errorf("unknown expectation %q (expected %s or %s)", expectation, ExpectNonEmpty, ExpectEmpty)
+	}
+
+	var fields map[string]interface{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, fmt.Errorf("could not read response data: %w", err)
+		}
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []string
+	for _, name := range names {
+		empty := isEmptyValue(fields[name])
+		switch {
+		case expectation == ExpectNonEmpty && empty:
+			errs = append(errs, fmt.Sprintf("Assertion failed: %s returned no rows, expected non-empty", name))
+		case expectation == ExpectEmpty && !empty:
+			errs = append(errs, fmt.Sprintf("Assertion failed: %s returned rows, expected empty", name))
+		}
+	}
+	return errs, nil
+}
+
+func isEmptyValue(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
@@ -0,0 +1,188 @@
+// Package engine is gql-validate's validation core: the config, GraphJin
+// setup, query file discovery, reading queries and their variables, and
+// checking GraphJin's responses. It has no command-line dependencies; the
+// cmd package builds the CLI on it and the validator package exposes it to
+// other Go programs.
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultExtensions are the query file extensions recognized when Options
+// lists none
+var DefaultExtensions = []string{".graphql"}
+
+// Options are the settings queries are found, read and checked with. The
+// zero value uses the defaults and enables no optional checks.
+type Options struct {
+	// Extensions are the file extensions of query files, with their dots
+	Extensions []string
+
+	// QueryDirs are searched for included partials that are not found next
+	// to the file including them
+	QueryDirs []string
+
+	// Env selects the <query>.<env>.json variables merged over each query's
+	// variables
+	Env string
+
+	// ShowSQL keeps the SQL GraphJin generated in results
+	ShowSQL bool
+
+	// Strict fails queries on warnings as well as errors
+	Strict bool
+
+	// NoNestedErrorScan disables looking for error keys in response data,
+	// and NestedErrorPaths restricts that scan to the given paths
+	NoNestedErrorScan bool
+	NestedErrorPaths  []string
+
+	// Operation selects the operation to run from documents that define
+	// several
+	Operation string
+
+	// Fragments are shared fragment definitions, keyed by name, appended
+	// to the queries that use them
+	Fragments map[string]string
+
+	// AllowedDirectives are query directives accepted on top of GraphJin's
+	// own
+	AllowedDirectives []string
+
+	// RequireDescription fails query files without a leading description
+	// comment
+	RequireDescription bool
+
+	// RequireNonEmpty applies the non-empty expectation to every query
+	// without its own "# expect:" header
+	RequireNonEmpty bool
+
+	// Budget and MaxResultBytes limit the run time and response size of
+	// queries without their own header; 0 disables either check
+	Budget         time.Duration
+	MaxResultBytes int
+
+	// MaxSQLStatements warns about read queries that run more SQL
+	// statements than this; 0 disables the check
+	MaxSQLStatements int
+
+	// DefaultRole and UserID are who queries run as, when they name no
+	// role of their own
+	DefaultRole string
+	UserID      string
+
+	// Schema is what the relationship and deprecation checks know about
+	// the database; nil skips them
+	Schema *Schema
+
+	// Snapshot holds the columns of each table of a schema snapshot that
+	// queries are checked against instead of running them
+	Snapshot map[string][]string
+
+	// Secrets are masked in the errors of results
+	Secrets []string
+
+	// Tracer starts a span for each query validated; nil starts none
+	Tracer trace.Tracer
+
+	// Logger receives progress messages; nil discards them
+	Logger Logger
+}
+
+// Logger receives the engine's debug and warning messages
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+}
+
+func (o Options) debugf(format string, args ...interface{}) {
+	if o.Logger != nil {
+		o.Logger.Debugf(format, args...)
+	}
+}
+
+func (o Options) warnf(format string, args ...interface{}) {
+	if o.Logger != nil {
+		o.Logger.Warnf(format, args...)
+	}
+}
+
+// extensions returns the query file extensions in use
+func (o Options) extensions() []string {
+	if len(o.Extensions) == 0 {
+		return DefaultExtensions
+	}
+	return o.Extensions
+}
+
+// NormalizeExtensions ensures every extension starts with a dot, dropping
+// empty ones
+func NormalizeExtensions(exts []string) []string {
+	normalized := make([]string, 0, len(exts))
+	for _, ext := range exts {
+		ext = strings.TrimSpace(ext)
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		normalized = append(normalized, ext)
+	}
+	return normalized
+}
+
+// QueryFileExt returns the recognized query extension of a file name, or
+// an empty string if the file is not a query file
+func (o Options) QueryFileExt(name string) string {
+	for _, ext := range o.extensions() {
+		if strings.HasSuffix(name, ext) {
+			return ext
+		}
+	}
+	return ""
+}
+
+// IsQueryFile reports whether a file name has a recognized query extension
+func (o Options) IsQueryFile(name string) bool {
+	return o.QueryFileExt(name) != ""
+}
+
+// QueryBasePath strips the matched query extension from a path, giving the
+// prefix used to locate companion files such as variables
+func (o Options) QueryBasePath(path string) string {
+	return strings.TrimSuffix(path, o.QueryFileExt(path))
+}
+
+// FindQueryFiles returns the query files under each of the given directories
+func (o Options) FindQueryFiles(dirs ...string) ([]string, error) {
+	var queryFiles []string
+	seen := make(map[string]bool)
+
+	for _, dir := range dirs {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			// Overlapping directories must not validate a file twice
+			if !info.IsDir() && o.IsQueryFile(info.Name()) && !seen[filepath.Clean(path)] {
+				seen[filepath.Clean(path)] = true
+				queryFiles = append(queryFiles, path)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return queryFiles, nil
+}
@@ -0,0 +1,163 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// fragmentDefPattern matches the head of a fragment definition
+	fragmentDefPattern = regexp.MustCompile(`\bfragment\s+([A-Za-z_][A-Za-z0-9_]*)\s+on\s+[A-Za-z_][A-Za-z0-9_]*`)
+
+	// fragmentSpreadPattern matches named fragment spreads such as ...UserFields
+	fragmentSpreadPattern = regexp.MustCompile(`\.\.\.\s*([A-Za-z_][A-Za-z0-9_]*)`)
+)
+
+// LoadFragments reads fragment definitions from a single file or from every
+// query file in a directory, keyed by fragment name
+func (o Options) LoadFragments(path string) (map[string]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read fragments: %w", err)
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		files, err = o.FindQueryFiles(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan fragments directory: %w", err)
+		}
+	}
+
+	fragments := make(map[string]string)
+	for _, file := range files {
+		content, err := ReadQuerySource(file)
+		if err != nil {
+			return nil, fmt.Errorf("could not read fragments file: %w", err)
+		}
+
+		defs, err := parseFragments(content)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(file), err)
+		}
+
+		for name, def := range defs {
+			if _, exists := fragments[name]; exists {
+				return nil, fmt.Errorf("fragment %q is defined more than once", name)
+			}
+			fragments[name] = def
+		}
+	}
+
+	return fragments, nil
+}
+
+// parseFragments extracts every fragment definition in a GraphQL document
+func parseFragments(src string) (map[string]string, error) {
+	fragments := make(map[string]string)
+
+	for _, loc := range fragmentDefPattern.FindAllStringSubmatchIndex(src, -1) {
+		name := src[loc[2]:loc[3]]
+
+		open := strings.Index(src[loc[1]:], "{")
+		if open < 0 {
+			return nil, fmt.Errorf("fragment %q has no selection set", name)
+		}
+
+		end := matchingBrace(src, loc[1]+open)
+		if end < 0 {
+			return nil, fmt.Errorf("fragment %q has an unterminated selection set", name)
+		}
+
+		fragments[name] = src[loc[0] : end+1]
+	}
+
+	return fragments, nil
+}
+
+// matchingBrace returns the index of the brace closing the one at open,
+// skipping over comments and string literals, or -1 if it is unbalanced
+func matchingBrace(src string, open int) int {
+	depth := 0
+
+	for i := open; i < len(src); i++ {
+		switch src[i] {
+		case '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case '"':
+			for i++; i < len(src) && src[i] != '"'; i++ {
+				if src[i] == '\\' {
+					i++
+				}
+			}
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// AppendFragments appends the shared fragments a query references (directly
+// or through other fragments) to the query. Fragments the query defines
+// itself take precedence and unused fragments are left out.
+func AppendFragments(query string, fragments map[string]string) string {
+	if len(fragments) == 0 {
+		return query
+	}
+
+	defined := make(map[string]bool)
+	for _, m := range fragmentDefPattern.FindAllStringSubmatch(query, -1) {
+		defined[m[1]] = true
+	}
+
+	used := make(map[string]bool)
+	pending := []string{query}
+
+	for len(pending) > 0 {
+		src := pending[0]
+		pending = pending[1:]
+
+		for _, m := range fragmentSpreadPattern.FindAllStringSubmatch(src, -1) {
+			name := m[1]
+			if name == "on" || defined[name] || used[name] {
+				continue
+			}
+			if def, ok := fragments[name]; ok {
+				used[name] = true
+				pending = append(pending, def)
+			}
+		}
+	}
+
+	if len(used) == 0 {
+		return query
+	}
+
+	names := make([]string, 0, len(used))
+	for name := range used {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(query)
+	for _, name := range names {
+		b.WriteString("\n\n")
+		b.WriteString(fragments[name])
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
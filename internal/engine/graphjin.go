@@ -0,0 +1,192 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	graphjin "github.com/dosco/graphjin/core"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// GraphJinConfig holds GraphJin settings passed through to its core.Config,
+// so validation can mirror a production GraphJin setup. Keys follow
+// GraphJin's own config file.
+type GraphJinConfig struct {
+	Blocklist       []string          `yaml:"blocklist"`
+	DefaultBlock    bool              `yaml:"default_block"`
+	SetUserID       bool              `yaml:"set_user_id"`
+	RolesQuery      string            `yaml:"roles_query"`
+	Vars            map[string]string `yaml:"variables"`
+	DefaultLimit    int               `yaml:"default_limit"`
+	DisableAgg      bool              `yaml:"disable_agg_functions"`
+	DisableFuncs    bool              `yaml:"disable_functions"`
+	EnableCamelcase bool              `yaml:"enable_camelcase"`
+	SingularSuffix  string            `yaml:"singular_suffix"`
+	Tables          []GraphJinTable   `yaml:"tables"`
+	Roles           []GraphJinRole    `yaml:"roles"`
+
+	// DefaultRole is the role queries run as when --roles is not given
+	DefaultRole string `yaml:"default_role"`
+	// UserID is the user queries run as, for $user_id and set_user_id
+	UserID string `yaml:"user_id"`
+}
+
+// GraphJinTable configures a table, e.g. an alias or its relationships
+type GraphJinTable struct {
+	Name      string              `yaml:"name"`
+	Schema    string              `yaml:"schema"`
+	Table     string              `yaml:"table"`
+	Type      string              `yaml:"type"`
+	Blocklist []string            `yaml:"blocklist"`
+	Columns   []GraphJinColumn    `yaml:"columns"`
+	OrderBy   map[string][]string `yaml:"order_by"`
+}
+
+// GraphJinColumn configures a table column
+type GraphJinColumn struct {
+	Name       string `yaml:"name"`
+	Type       string `yaml:"type"`
+	Primary    bool   `yaml:"primary"`
+	Array      bool   `yaml:"array"`
+	ForeignKey string `yaml:"related_to"`
+}
+
+// GraphJinRole configures what a role may do with each table
+type GraphJinRole struct {
+	Name   string              `yaml:"name"`
+	Match  string              `yaml:"match"`
+	Tables []GraphJinRoleTable `yaml:"tables"`
+}
+
+// GraphJinRoleTable configures a table's access rules for a role
+type GraphJinRoleTable struct {
+	Name     string             `yaml:"name"`
+	Schema   string             `yaml:"schema"`
+	ReadOnly bool               `yaml:"read_only"`
+	Query    *GraphJinQueryRule `yaml:"query"`
+	Insert   *GraphJinWriteRule `yaml:"insert"`
+	Update   *GraphJinWriteRule `yaml:"update"`
+	Upsert   *GraphJinWriteRule `yaml:"upsert"`
+	Delete   *GraphJinWriteRule `yaml:"delete"`
+}
+
+// GraphJinQueryRule limits what a role may read from a table
+type GraphJinQueryRule struct {
+	Limit            int      `yaml:"limit"`
+	Filters          []string `yaml:"filters"`
+	Columns          []string `yaml:"columns"`
+	DisableFunctions bool     `yaml:"disable_functions"`
+	Block            bool     `yaml:"block"`
+}
+
+// GraphJinWriteRule limits what a role may write to a table; presets do
+// not apply to deletes
+type GraphJinWriteRule struct {
+	Filters []string          `yaml:"filters"`
+	Columns []string          `yaml:"columns"`
+	Presets map[string]string `yaml:"presets"`
+	Block   bool              `yaml:"block"`
+}
+
+// Apply copies the settings onto a GraphJin config
+func (g GraphJinConfig) Apply(conf *graphjin.Config) {
+	conf.Blocklist = g.Blocklist
+	conf.DefaultBlock = g.DefaultBlock
+	conf.SetUserID = g.SetUserID
+	conf.RolesQuery = g.RolesQuery
+	conf.Vars = g.Vars
+	conf.DefaultLimit = g.DefaultLimit
+	conf.DisableAgg = g.DisableAgg
+	conf.DisableFuncs = g.DisableFuncs
+	conf.EnableCamelcase = g.EnableCamelcase
+	conf.SingularSuffix = g.SingularSuffix
+
+	for _, t := range g.Tables {
+		table := graphjin.Table{
+			Name:      t.Name,
+			Schema:    t.Schema,
+			Table:     t.Table,
+			Type:      t.Type,
+			Blocklist: t.Blocklist,
+			OrderBy:   t.OrderBy,
+		}
+		for _, c := range t.Columns {
+			table.Columns = append(table.Columns, graphjin.Column(c))
+		}
+		conf.Tables = append(conf.Tables, table)
+	}
+
+	for _, r := range g.Roles {
+		role := graphjin.Role{Name: r.Name, Match: r.Match}
+		for _, t := range r.Tables {
+			role.Tables = append(role.Tables, t.roleTable())
+		}
+		conf.Roles = append(conf.Roles, role)
+	}
+}
+
+// roleTable converts a role's table rules into GraphJin's form
+func (t GraphJinRoleTable) roleTable() graphjin.RoleTable {
+	rt := graphjin.RoleTable{Name: t.Name, Schema: t.Schema, ReadOnly: t.ReadOnly}
+	if q := t.Query; q != nil {
+		rt.Query = &graphjin.Query{Limit: q.Limit, Filters: q.Filters, Columns: q.Columns, DisableFunctions: q.DisableFunctions, Block: q.Block}
+	}
+	if w := t.Insert; w != nil {
+		rt.Insert = &graphjin.Insert{Filters: w.Filters, Columns: w.Columns, Presets: w.Presets, Block: w.Block}
+	}
+	if w := t.Update; w != nil {
+		rt.Update = &graphjin.Update{Filters: w.Filters, Columns: w.Columns, Presets: w.Presets, Block: w.Block}
+	}
+	if w := t.Upsert; w != nil {
+		rt.Upsert = &graphjin.Upsert{Filters: w.Filters, Columns: w.Columns, Presets: w.Presets, Block: w.Block}
+	}
+	if w := t.Delete; w != nil {
+		rt.Delete = &graphjin.Delete{Filters: w.Filters, Columns: w.Columns, Block: w.Block}
+	}
+	return rt
+}
+
+// Connect opens and pings the configured database
+func Connect(config *Config) (*sql.DB, error) {
+	db, err := sql.Open("pgx", config.GetDSN())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+	return db, nil
+}
+
+// NewGraphJin creates a GraphJin instance for validation on db, with the
+// config's graphjin section, debug and production settings. The allow list
+// is disabled so any query can be compiled.
+func NewGraphJin(config *Config, db *sql.DB) (*graphjin.GraphJin, error) {
+	gjConfig := &graphjin.Config{}
+	config.GraphJin.Apply(gjConfig)
+	gjConfig.Debug = config.GraphJinDebug
+	gjConfig.Production = config.Production
+	gjConfig.DisableAllowList = true
+
+	gj, err := graphjin.NewGraphJin(gjConfig, db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GraphJin instance: %w", err)
+	}
+	return gj, nil
+}
+
+// WarmUpQuery is a trivial introspection query used to finish loading the
+// schema before any query is timed
+const WarmUpQuery = `query IntrospectionQuery { __schema { queryType { name } } }`
+
+// WarmUp runs a throwaway query so the one-time cost of building GraphJin's
+// schema and opening connections is not charged to the first query's
+// duration. Errors are ignored; the query is only run for its side effects.
+func WarmUp(gj *graphjin.GraphJin) time.Duration {
+	start := time.Now()
+	_, _ = gj.GraphQL(context.Background(), WarmUpQuery, nil, nil)
+	return time.Since(start)
+}
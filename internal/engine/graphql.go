@@ -0,0 +1,137 @@
+package engine
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/chirino/graphql/qerrors"
+	"github.com/chirino/graphql/schema"
+)
+
+// ParseDocument parses a GraphQL query document into its AST
+func ParseDocument(query string) (*schema.QueryDocument, error) {
+	doc := &schema.QueryDocument{}
+	if err := doc.Parse(query); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// PositionedSyntaxError returns the first syntax error in a query formatted
+// as "<path>:<line>:<column>: <message>", or an empty string if the query
+// parses or the parser reported no position. The position is mapped through
+// source to the file holding the error, when there is a source map.
+func PositionedSyntaxError(path, query string, source SourceMap) string {
+	_, err := ParseDocument(query)
+
+	var qe *qerrors.Error
+	if err == nil || !errors.As(err, &qe) || len(qe.Locations) == 0 {
+		return ""
+	}
+
+	loc := qe.Locations[0]
+	return fmt.Sprintf("%s: %s", source.Position(path, loc.Line, loc.Column), qe.Message)
+}
+
+// TableFieldName maps a GraphJin selector name onto its table name,
+// stripping the singular "ById" suffix GraphJin generates for lookups
+func TableFieldName(name string) string {
+	return strings.TrimSuffix(name, "ById")
+}
+
+// SelectionFields flattens a selection list into its field selections,
+// expanding inline fragments and fragment spreads defined in the document
+func SelectionFields(doc *schema.QueryDocument, sels schema.SelectionList) []*schema.FieldSelection {
+	var fields []*schema.FieldSelection
+	seen := make(map[string]bool)
+
+	var walk func(sels schema.SelectionList)
+	walk = func(sels schema.SelectionList) {
+		for _, sel := range sels {
+			switch s := sel.(type) {
+			case *schema.FieldSelection:
+				fields = append(fields, s)
+			case *schema.InlineFragment:
+				walk(s.Selections)
+			case *schema.FragmentSpread:
+				if seen[s.Name] {
+					continue
+				}
+				seen[s.Name] = true
+				if frag := doc.Fragments.Get(s.Name); frag != nil {
+					walk(frag.Selections)
+				}
+			}
+		}
+	}
+
+	walk(sels)
+	return fields
+}
+
+// LookupTable resolves a selector name onto a table, accounting for the
+// singular names GraphJin accepts for single-row selections
+func LookupTable(name string, tables map[string][]string) string {
+	name = TableFieldName(name)
+
+	candidates := []string{name, name + "s", name + "es"}
+	if strings.HasSuffix(name, "y") {
+		candidates = append(candidates, strings.TrimSuffix(name, "y")+"ies")
+	}
+
+	for _, candidate := range candidates {
+		if _, ok := tables[candidate]; ok {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// CollectColumnRefs walks a selection set, recording leaf fields as columns
+// of the enclosing table and nested selections as related tables
+func CollectColumnRefs(doc *schema.QueryDocument, sels schema.SelectionList, table string,
+	tables map[string][]string, referenced map[string]map[string]bool) {
+	for _, field := range SelectionFields(doc, sels) {
+		if len(field.Selections) > 0 {
+			child := LookupTable(field.Name, tables)
+			if child != "" && referenced[child] == nil {
+				referenced[child] = make(map[string]bool)
+			}
+			CollectColumnRefs(doc, field.Selections, child, tables, referenced)
+			continue
+		}
+
+		if table != "" {
+			referenced[table][field.Name] = true
+		}
+	}
+}
+
+// LoadTableColumns returns the columns of every table in the given schema
+func LoadTableColumns(db *sql.DB, schema string) (map[string][]string, error) {
+	rows, err := db.Query(`
+		SELECT c.table_name, c.column_name
+		FROM information_schema.columns c
+		JOIN information_schema.tables t
+		  ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+		WHERE c.table_schema = $1 AND t.table_type = 'BASE TABLE'
+		ORDER BY c.table_name, c.ordinal_position
+	`, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tables := make(map[string][]string)
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			return nil, err
+		}
+		tables[table] = append(tables[table], column)
+	}
+
+	return tables, rows.Err()
+}
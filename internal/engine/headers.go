@@ -0,0 +1,204 @@
+package engine
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// headerDirectivePattern matches a "# key: value" directive line in a
+// query's leading comment block, optionally scoped as "# key[role]: value"
+var headerDirectivePattern = regexp.MustCompile(`^#\s*([a-z][a-z0-9_-]*)(?:\[([^\]]+)\])?:\s*(.*)$`)
+
+// MissingDescriptionError is reported for query files without a description
+const MissingDescriptionError = "Missing description: add a leading # comment describing the query"
+
+// knownHeaderDirectives lists the directives recognized in query headers
+var knownHeaderDirectives = map[string]bool{
+	"tags":             true,
+	"expect":           true,
+	"include":          true,
+	"allowed-roles":    true,
+	"budget":           true,
+	"max-result-bytes": true,
+}
+
+// QueryHeader holds what a query file's leading comment block declares
+type QueryHeader struct {
+	// Description is the first comment line that is not a directive, such
+	// as "# Lists active users" or "# note: returns only active users"
+	Description string
+	// Tags are collected from every "# tags:" line
+	Tags []string
+	// AllowedRoles are the roles from "# allowed-roles:" lines, nil when
+	// there are none
+	AllowedRoles []string
+	// Unknown lists directive keys that look like misspelled directives or
+	// cannot be scoped to a role
+	Unknown []string
+
+	// QuerySettings holds the unscoped settings directives
+	QuerySettings
+	// RoleSettings holds the settings directives scoped to each role
+	RoleSettings map[string]QuerySettings
+}
+
+// QuerySettings holds the directives that can be scoped to a role. Values
+// are kept as written and checked when the query runs; the first line for
+// each directive wins.
+type QuerySettings struct {
+	// Expect is the lowercased value of the "# expect:" line
+	Expect string
+	// Budget is the "# budget:" duration the query must finish within
+	Budget string
+	// MaxResultBytes is the "# max-result-bytes:" response size limit
+	MaxResultBytes string
+}
+
+// ParseHeader scans the comment lines at the top of a query file, up to the
+// first line that is neither a comment nor blank
+func ParseHeader(content string) QueryHeader {
+	var header QueryHeader
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+
+		// Lines such as "# note: ..." are description text unless the key is
+		// a directive, is scoped to a role or looks like a misspelled one
+		m := headerDirectivePattern.FindStringSubmatch(line)
+		if m == nil || m[2] == "" && !knownHeaderDirectives[m[1]] && !misspelledDirective(m[1]) {
+			if header.Description == "" {
+				header.Description = strings.TrimSpace(strings.TrimPrefix(line, "#"))
+			}
+			continue
+		}
+
+		key, role, value := m[1], strings.TrimSpace(m[2]), strings.TrimSpace(m[3])
+		if role != "" {
+			if !header.setRoleSetting(role, key, value) {
+				header.Unknown = append(header.Unknown, key+"["+role+"]")
+			}
+			continue
+		}
+
+		switch key {
+		case "tags":
+			for _, tag := range strings.Split(value, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					header.Tags = append(header.Tags, tag)
+				}
+			}
+		case "allowed-roles":
+			for _, role := range strings.Split(value, ",") {
+				if role = strings.TrimSpace(role); role != "" {
+					header.AllowedRoles = append(header.AllowedRoles, role)
+				}
+			}
+		default:
+			if !header.QuerySettings.set(key, value) && !knownHeaderDirectives[key] {
+				header.Unknown = append(header.Unknown, key)
+			}
+		}
+	}
+
+	return header
+}
+
+// misspelledDirective reports whether key is within two edits of a known
+// directive, such as "tag" or "allowed_roles"
+func misspelledDirective(key string) bool {
+	key = strings.ReplaceAll(key, "_", "-")
+	for known := range knownHeaderDirectives {
+		if editDistance(key, known) <= 2 {
+			return true
+		}
+	}
+	return false
+}
+
+// editDistance returns the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// set records a settings directive unless an earlier line set it, reporting
+// whether key is a settings directive
+func (s *QuerySettings) set(key, value string) bool {
+	var field *string
+	switch key {
+	case "expect":
+		field, value = &s.Expect, strings.ToLower(value)
+	case "budget":
+		field = &s.Budget
+	case "max-result-bytes":
+		field = &s.MaxResultBytes
+	default:
+		return false
+	}
+	if *field == "" {
+		*field = value
+	}
+	return true
+}
+
+// setRoleSetting records a settings directive scoped to role, reporting
+// whether key can be scoped
+func (h *QueryHeader) setRoleSetting(role, key, value string) bool {
+	settings := h.RoleSettings[role]
+	if !settings.set(key, value) {
+		return false
+	}
+	if h.RoleSettings == nil {
+		h.RoleSettings = make(map[string]QuerySettings)
+	}
+	h.RoleSettings[role] = settings
+	return true
+}
+
+// ForRole returns the settings a query runs with as role, preferring the
+// directives scoped to that role over the unscoped ones
+func (h QueryHeader) ForRole(role string) QuerySettings {
+	settings := h.QuerySettings
+	scoped := h.RoleSettings[role]
+	if scoped.Expect != "" {
+		settings.Expect = scoped.Expect
+	}
+	if scoped.Budget != "" {
+		settings.Budget = scoped.Budget
+	}
+	if scoped.MaxResultBytes != "" {
+		settings.MaxResultBytes = scoped.MaxResultBytes
+	}
+	return settings
+}
+
+// Warnings describes the problems found in the header
+func (h QueryHeader) Warnings() []string {
+	var warnings []string
+	for _, key := range h.Unknown {
+		warnings = append(warnings, fmt.Sprintf("Unknown header directive %q", key))
+	}
+	return warnings
+}
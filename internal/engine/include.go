@@ -0,0 +1,157 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// includeHeaderPattern matches "# include: <path>" lines, which are
+// replaced by the contents of the partial file they name
+var includeHeaderPattern = regexp.MustCompile(`^\s*#\s*include:\s*(\S+)\s*$`)
+
+// SourceLine is the file and line a line of an expanded query came from.
+// Column is added to the columns on that line, for queries that start
+// partway through it, such as a Go string literal.
+type SourceLine struct {
+	Path   string
+	Line   int
+	Column int
+}
+
+// SourceMap maps the lines of an expanded query, in order, back to the
+// files they came from
+type SourceMap []SourceLine
+
+// fileSourceMap maps each line of content onto the same line of path
+func fileSourceMap(path, content string) SourceMap {
+	return EmbeddedSourceMap(path, content, 1, 0)
+}
+
+// EmbeddedSourceMap maps the lines of a query embedded in path, whose first
+// line is at line and follows column characters of that line
+func EmbeddedSourceMap(path, query string, line, column int) SourceMap {
+	m := make(SourceMap, strings.Count(query, "\n")+1)
+	for i := range m {
+		m[i] = SourceLine{Path: path, Line: line + i}
+	}
+	m[0].Column = column
+	return m
+}
+
+// Position formats a 1-based line and column of the expanded query as
+// "<path>:<line>:<column>" in the file that holds it. Without a mapping
+// for the line, the position is reported in path as is.
+func (m SourceMap) Position(path string, line, column int) string {
+	if line < 1 || line > len(m) {
+		return fmt.Sprintf("%s:%d:%d", path, line, column)
+	}
+	src := m[line-1]
+	return fmt.Sprintf("%s:%d:%d", src.Path, src.Line, src.Column+column)
+}
+
+// ReadQueryFile reads a query file with its includes resolved, along with
+// where each line of the result came from
+func (o Options) ReadQueryFile(path string) (string, SourceMap, error) {
+	content, err := ReadQuerySource(path)
+	if err != nil {
+		return "", nil, err
+	}
+	return o.expandIncludes(path, content, []string{filepath.Clean(path)})
+}
+
+// expandIncludes splices the partials named by include lines into content,
+// recursively, mapping each resulting line back to its file. stack holds
+// the files being expanded, to detect cycles.
+func (o Options) expandIncludes(path, content string, stack []string) (string, SourceMap, error) {
+	if !strings.Contains(content, "include:") {
+		return content, fileSourceMap(path, content), nil
+	}
+
+	lines := strings.Split(content, "\n")
+	var source SourceMap
+	for i, line := range lines {
+		m := includeHeaderPattern.FindStringSubmatch(line)
+		if m == nil {
+			source = append(source, SourceLine{Path: path, Line: i + 1})
+			continue
+		}
+
+		partial := o.resolveIncludePath(path, m[1])
+		for _, p := range stack {
+			if p == partial {
+				return "", nil, fmt.Errorf("include cycle: %s -> %s", strings.Join(stack, " -> "), partial)
+			}
+		}
+
+		data, err := ReadQuerySource(partial)
+		if err != nil {
+			return "", nil, fmt.Errorf("include %s: %w", m[1], err)
+		}
+
+		expanded, partialSource, err := o.expandIncludes(partial, strings.TrimRight(data, "\n"), append(stack, partial))
+		if err != nil {
+			return "", nil, err
+		}
+		lines[i] = expanded
+		source = append(source, partialSource...)
+	}
+
+	return strings.Join(lines, "\n"), source, nil
+}
+
+// resolveIncludePath locates an included partial relative to the including
+// file, falling back to the queries directories so shared partials can be
+// named from anywhere in the tree
+func (o Options) resolveIncludePath(from, name string) string {
+	if filepath.IsAbs(name) {
+		return filepath.Clean(name)
+	}
+
+	local := filepath.Join(filepath.Dir(from), name)
+	if _, err := os.Stat(local); err == nil {
+		return local
+	}
+
+	for _, root := range o.QueryDirs {
+		if root == "" {
+			continue
+		}
+		candidate := filepath.Join(root, name)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+
+	return local
+}
+
+// ExcludePartialFiles drops files that other query files include, since
+// partials are selection blocks rather than standalone operations
+func (o Options) ExcludePartialFiles(queryFiles []string) []string {
+	partials := make(map[string]bool)
+	for _, qf := range queryFiles {
+		content, err := ReadQuerySource(qf)
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(content, "\n") {
+			if m := includeHeaderPattern.FindStringSubmatch(line); m != nil {
+				partials[o.resolveIncludePath(qf, m[1])] = true
+			}
+		}
+	}
+	if len(partials) == 0 {
+		return queryFiles
+	}
+
+	filtered := queryFiles[:0]
+	for _, qf := range queryFiles {
+		if !partials[filepath.Clean(qf)] {
+			filtered = append(filtered, qf)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,109 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/chirino/graphql/schema"
+	graphjin "github.com/dosco/graphjin/core"
+)
+
+// AnonymousOperation is how operations without a name, including shorthand
+// "{ ... }" queries, are reported
+const AnonymousOperation = "(anonymous)"
+
+// SelectOperation narrows a document to a single operation for GraphJin,
+// which only runs the first operation it finds. Documents with one
+// operation are returned unchanged; otherwise the named operation is
+// returned along with the document's fragments. Documents that fail to
+// parse are left for GraphJin to report.
+func SelectOperation(query, name string) (string, error) {
+	doc, err := ParseDocument(query)
+	if err != nil {
+		return query, nil
+	}
+
+	if name == "" {
+		if len(doc.Operations) <= 1 {
+			return query, nil
+		}
+		return "", fmt.Errorf("Operation not selected: the document defines %d operations (%s), choose one with --operation or an operation field, or key the variables by operation name",
+			len(doc.Operations), strings.Join(OperationNames(doc.Operations), ", "))
+	}
+
+	op := doc.Operations.Get(name)
+	if op == nil {
+		return "", fmt.Errorf("Operation not selected: %q is not defined in the document (available: %s)",
+			name, strings.Join(OperationNames(doc.Operations), ", "))
+	}
+	if len(doc.Operations) == 1 {
+		return query, nil
+	}
+
+	var b strings.Builder
+	WriteOperation(&b, op)
+	for _, frag := range doc.Fragments {
+		b.WriteString("\n")
+		WriteFragment(&b, frag)
+	}
+	return b.String(), nil
+}
+
+// SplitOperationVariables returns each operation's variables when a
+// multi-operation document's variables are keyed by operation name, e.g.
+// {"A": {"x": 1}, "B": {"y": "b"}}, along with the operation names in
+// document order. ok is false for flat variables, which single-operation
+// documents always use.
+func SplitOperationVariables(query string, variables json.RawMessage) (perOp map[string]json.RawMessage, names []string, ok bool) {
+	doc, err := ParseDocument(query)
+	if err != nil || len(doc.Operations) <= 1 {
+		return nil, nil, false
+	}
+	if err := json.Unmarshal(variables, &perOp); err != nil || len(perOp) == 0 {
+		return nil, nil, false
+	}
+
+	for key, value := range perOp {
+		if doc.Operations.Get(key) == nil || !strings.HasPrefix(strings.TrimSpace(string(value)), "{") {
+			return nil, nil, false
+		}
+	}
+	for _, op := range doc.Operations {
+		if op.Name == "" {
+			return nil, nil, false
+		}
+		names = append(names, op.Name)
+	}
+	return perOp, names, true
+}
+
+// OperationNames lists a document's operation names in order, showing
+// unnamed operations as "(anonymous)"
+func OperationNames(ops schema.OperationList) []string {
+	names := make([]string, 0, len(ops))
+	for _, op := range ops {
+		names = append(names, DisplayOperationName(op.Name))
+	}
+	return names
+}
+
+// DisplayOperationName returns name, or "(anonymous)" when it is empty
+func DisplayOperationName(name string) string {
+	if name == "" {
+		return AnonymousOperation
+	}
+	return name
+}
+
+// DescribeOperation returns the type keyword and display name of a query's
+// first operation. Shorthand "{ ... }" queries, with or without leading
+// comments, are anonymous queries. ok is false when the query cannot be
+// parsed.
+func DescribeOperation(query string) (opType, name string, ok bool) {
+	h, err := graphjin.Operation(query)
+	if err != nil {
+		return "", "", false
+	}
+	return OperationTypeName(h.Type), DisplayOperationName(h.Name), true
+}
@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// executeSpanName is the span GraphJin wraps a query's database round trip
+// in. GraphJin has no separate compile call, so this span is what splits a
+// query's time into compiling and executing.
+const executeSpanName = "Execute Query"
+
+// statementSpanNames are the spans GraphJin wraps each SQL statement it runs
+// for a request in, the query itself included
+var statementSpanNames = map[string]bool{
+	executeSpanName:      true,
+	"Execute Role Query": true,
+	"Set Local User ID":  true,
+}
+
+// queryPhasesKey carries a query's *queryPhases in the context GraphJin
+// runs it with
+type queryPhasesKey struct{}
+
+// queryPhases accumulates the time GraphJin spent executing a query's SQL,
+// retries included, and counts the SQL statements it ran
+type queryPhases struct {
+	mu         sync.Mutex
+	execute    time.Duration
+	statements int
+}
+
+// withQueryPhases returns a context whose GraphJin execute spans are timed
+// into the returned queryPhases
+func withQueryPhases(ctx context.Context) (context.Context, *queryPhases) {
+	phases := &queryPhases{}
+	return context.WithValue(ctx, queryPhasesKey{}, phases), phases
+}
+
+func (p *queryPhases) executed() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.execute
+}
+
+func (p *queryPhases) statementCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.statements
+}
+
+// PhaseTimer is a span processor that times GraphJin's execute spans and
+// counts its statement spans for the query whose context started them.
+// Without it registered on the global tracer provider, results report no
+// execute time and the SQL statement check is skipped.
+type PhaseTimer struct {
+	// spans maps the ID of each execute span in progress to its query
+	spans sync.Map
+}
+
+func (t *PhaseTimer) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	if !statementSpanNames[s.Name()] {
+		return
+	}
+	phases, ok := parent.Value(queryPhasesKey{}).(*queryPhases)
+	if !ok {
+		return
+	}
+
+	phases.mu.Lock()
+	phases.statements++
+	phases.mu.Unlock()
+	if s.Name() == executeSpanName {
+		t.spans.Store(s.SpanContext().SpanID(), phases)
+	}
+}
+
+func (t *PhaseTimer) OnEnd(s sdktrace.ReadOnlySpan) {
+	if v, ok := t.spans.LoadAndDelete(s.SpanContext().SpanID()); ok {
+		phases := v.(*queryPhases)
+		phases.mu.Lock()
+		phases.execute += s.EndTime().Sub(s.StartTime())
+		phases.mu.Unlock()
+	}
+}
+
+func (t *PhaseTimer) Shutdown(context.Context) error   { return nil }
+func (t *PhaseTimer) ForceFlush(context.Context) error { return nil }
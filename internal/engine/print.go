@@ -0,0 +1,100 @@
+package engine
+
+import (
+	"strings"
+
+	"github.com/chirino/graphql/schema"
+)
+
+// printIndent is the indentation used for each level of a selection set
+const printIndent = "  "
+
+// WriteOperation prints an operation in the canonical format of gql-validate fmt
+func WriteOperation(b *strings.Builder, op *schema.Operation) {
+	b.WriteString(string(op.Type))
+	if op.Name != "" {
+		b.WriteString(" " + op.Name)
+	}
+	writeVariables(b, op.Vars)
+	writeDirectives(b, op.Directives)
+	WriteSelections(b, op.Selections, 0)
+	b.WriteString("\n")
+}
+
+// WriteFragment prints a fragment definition in the canonical format
+func WriteFragment(b *strings.Builder, frag *schema.FragmentDecl) {
+	b.WriteString("fragment " + frag.Name + " on " + frag.On.Name)
+	writeDirectives(b, frag.Directives)
+	WriteSelections(b, frag.Selections, 0)
+	b.WriteString("\n")
+}
+
+func writeVariables(b *strings.Builder, vars schema.InputValueList) {
+	if len(vars) == 0 {
+		return
+	}
+
+	parts := make([]string, 0, len(vars))
+	for _, v := range vars {
+		part := "$" + strings.TrimPrefix(v.Name, "$") + ": " + v.Type.String()
+		if v.Default != nil {
+			part += " = " + v.Default.String()
+		}
+		parts = append(parts, part)
+	}
+	b.WriteString("(" + strings.Join(parts, ", ") + ")")
+}
+
+func writeArguments(b *strings.Builder, args schema.ArgumentList) {
+	if len(args) == 0 {
+		return
+	}
+
+	parts := make([]string, 0, len(args))
+	for _, arg := range args {
+		parts = append(parts, arg.Name+": "+arg.Value.String())
+	}
+	b.WriteString("(" + strings.Join(parts, ", ") + ")")
+}
+
+func writeDirectives(b *strings.Builder, directives schema.DirectiveList) {
+	for _, d := range directives {
+		b.WriteString(" @" + d.Name)
+		writeArguments(b, d.Args)
+	}
+}
+
+// WriteSelections prints a selection set indented depth levels deep
+func WriteSelections(b *strings.Builder, sels schema.SelectionList, depth int) {
+	if len(sels) == 0 {
+		return
+	}
+
+	indent := strings.Repeat(printIndent, depth+1)
+	b.WriteString(" {\n")
+	for _, sel := range sels {
+		b.WriteString(indent)
+		switch s := sel.(type) {
+		case *schema.FieldSelection:
+			if s.Alias != "" && s.Alias != s.Name {
+				b.WriteString(s.Alias + ": ")
+			}
+			b.WriteString(s.Name)
+			writeArguments(b, s.Arguments)
+			writeDirectives(b, s.Directives)
+			WriteSelections(b, s.Selections, depth+1)
+		case *schema.InlineFragment:
+			b.WriteString("...")
+			if s.On.Name != "" {
+				b.WriteString(" on " + s.On.Name)
+			}
+			writeDirectives(b, s.Directives)
+			WriteSelections(b, s.Selections, depth+1)
+		case *schema.FragmentSpread:
+			b.WriteString("..." + s.Name)
+			writeDirectives(b, s.Directives)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(strings.Repeat(printIndent, depth) + "}")
+}
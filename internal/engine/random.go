@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"context"
+	"hash/fnv"
+	"math/rand"
+	"sync/atomic"
+	"text/template"
+	"time"
+)
+
+// variableRandomKey carries a run's *variableRandom in the validation context
+type variableRandomKey struct{}
+
+// variableRandom is a run's seeded source of random variable values
+type variableRandom struct {
+	seed int64
+	// used records whether any random value was generated
+	used atomic.Bool
+}
+
+// WithRandom returns a context whose variables draw their random values
+// from seed
+func WithRandom(ctx context.Context, seed int64) context.Context {
+	return context.WithValue(ctx, variableRandomKey{}, &variableRandom{seed: seed})
+}
+
+// runRandom returns the random source of the run ctx belongs to, or one
+// seeded from the clock when ctx has none
+func runRandom(ctx context.Context) *variableRandom {
+	if r, ok := ctx.Value(variableRandomKey{}).(*variableRandom); ok {
+		return r
+	}
+	return &variableRandom{seed: time.Now().UnixNano()}
+}
+
+// randomAlphabet is the character set used by randString
+const randomAlphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+// funcs returns the random template functions for the variables at key,
+// drawing from a source derived from the run's seed and key alone. A seed
+// therefore reproduces the same values for a variables file whichever
+// order, target or worker it is expanded in.
+func (v *variableRandom) funcs(key string) template.FuncMap {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	r := rand.New(rand.NewSource(v.seed ^ int64(h.Sum64())))
+
+	return template.FuncMap{
+		// randInt returns a random integer in [min, max]
+		"randInt": func(min, max int) int {
+			v.used.Store(true)
+			if max <= min {
+				return min
+			}
+			return min + r.Intn(max-min+1)
+		},
+		// randString returns a random lowercase alphanumeric string of length n
+		"randString": func(n int) string {
+			v.used.Store(true)
+			b := make([]byte, n)
+			for i := range b {
+				b[i] = randomAlphabet[r.Intn(len(randomAlphabet))]
+			}
+			return string(b)
+		},
+	}
+}
+
+// RandomUsed reports whether the run ctx belongs to generated any random
+// value
+func RandomUsed(ctx context.Context) bool {
+	return runRandom(ctx).used.Load()
+}
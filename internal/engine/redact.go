@@ -0,0 +1,53 @@
+package engine
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// redactedValue replaces secrets in reported errors
+const redactedValue = "xxxxx"
+
+// minSecretLength is the shortest secret masked by value; shorter values
+// would mask unrelated text and are only caught by the DSN patterns
+const minSecretLength = 4
+
+var (
+	// dsnSecretPattern matches secret settings in key=value DSNs
+	dsnSecretPattern = regexp.MustCompile(`(?i)\b(password|sslpassword|sslkey)=('(?:[^'\\]|\\.)*'|[^\s&]+)`)
+
+	// urlPasswordPattern matches the password in a URL's user info
+	urlPasswordPattern = regexp.MustCompile(`(://[^:/@\s]*:)[^@\s]+@`)
+)
+
+// Secrets returns the password and key path of a database config, as
+// masked by Redact. Values too short to mask safely are left out.
+func Secrets(db DatabaseConfig) []string {
+	candidates := []string{db.Password, db.SSLKey}
+	if u, err := url.Parse(db.URL); err == nil && u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			candidates = append(candidates, password, url.QueryEscape(password))
+		}
+		candidates = append(candidates, u.Query().Get("sslkey"))
+	}
+
+	var secrets []string
+	for _, s := range candidates {
+		if len(s) >= minSecretLength {
+			secrets = append(secrets, s)
+		}
+	}
+	return secrets
+}
+
+// Redact masks passwords and key paths in DSNs, URLs and any of secrets in
+// a message
+func Redact(s string, secrets []string) string {
+	s = dsnSecretPattern.ReplaceAllString(s, "${1}="+redactedValue)
+	s = urlPasswordPattern.ReplaceAllString(s, "${1}"+redactedValue+"@")
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, redactedValue)
+	}
+	return s
+}
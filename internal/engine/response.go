@@ -0,0 +1,193 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	graphjin "github.com/dosco/graphjin/core"
+)
+
+// warningPattern matches the leading classifier of informational and
+// deprecation messages GraphJin returns alongside results, such as
+// "Warning: ..." or "Deprecated ...", which do not make a query invalid.
+// Only the first word counts, so an error naming a "deprecated" column or
+// an "information" table is still an error.
+var warningPattern = regexp.MustCompile(`(?i)^(warning|notice|info|deprecat\w*)\b\s*:?`)
+
+// SplitWarnings separates warnings from errors in response messages. With
+// Strict every message is an error.
+func (o Options) SplitWarnings(messages []string) (errs, warnings []string) {
+	for _, msg := range messages {
+		if !o.Strict && warningPattern.MatchString(msg) {
+			warnings = append(warnings, msg)
+		} else {
+			errs = append(errs, msg)
+		}
+	}
+	return errs, warnings
+}
+
+// ResponseErrors returns the errors and warnings of a GraphJin response:
+// its GraphQL errors, then the error keys nested in its data unless
+// NoNestedErrorScan is set
+func (o Options) ResponseErrors(res *graphjin.Result) (errs, warnings []string) {
+	var messages []string
+	for _, gjErr := range res.Errors {
+		messages = append(messages, gjErr.Message)
+	}
+	errs, warnings = o.SplitWarnings(messages)
+
+	if len(res.Data) > 0 && !o.NoNestedErrorScan {
+		errs = append(errs, o.FindNestedErrors(res.Data)...)
+	}
+	return errs, warnings
+}
+
+// FindNestedErrors recursively searches for error fields in the GraphQL response data
+func (o Options) FindNestedErrors(data json.RawMessage) []string {
+	var errors []string
+
+	if len(data) == 0 {
+		return errors
+	}
+
+	var result interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return errors
+	}
+
+	o.collectErrors(result, &errors, "")
+	return errors
+}
+
+// nestedErrorPrefix matches the location prefix added by collectErrors
+var nestedErrorPrefix = regexp.MustCompile(`^Error at [^:]+: `)
+
+// DedupeErrors collapses messages that repeat at multiple paths into their
+// first occurrence with an occurrence count appended as " (×N)", keeping
+// first-seen order. Keeping the first path lets toResultError still classify
+// a collapsed nested error, with the count split off into its own field.
+func DedupeErrors(errs []string) []string {
+	if len(errs) < 2 {
+		return errs
+	}
+
+	counts := make(map[string]int)
+	var order []string
+	first := make(map[string]string)
+
+	for _, e := range errs {
+		msg := nestedErrorPrefix.ReplaceAllString(e, "")
+		if counts[msg] == 0 {
+			order = append(order, msg)
+			first[msg] = e
+		}
+		counts[msg]++
+	}
+
+	deduped := make([]string, 0, len(order))
+	for _, msg := range order {
+		if counts[msg] == 1 {
+			deduped = append(deduped, first[msg])
+			continue
+		}
+		deduped = append(deduped, fmt.Sprintf("%s (×%d)", first[msg], counts[msg]))
+	}
+
+	return deduped
+}
+
+// collectErrors recursively walks through the data structure looking for error indicators
+func (o Options) collectErrors(data interface{}, errors *[]string, path string) {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		scan := o.nestedErrorPathAllowed(path)
+
+		// Check for "errors" key (array of errors)
+		if errs, ok := v["errors"]; ok && errs != nil && scan {
+			if errArray, ok := errs.([]interface{}); ok && len(errArray) > 0 {
+				for i, e := range errArray {
+					if errMap, ok := e.(map[string]interface{}); ok {
+						if msg, ok := errMap["message"].(string); ok {
+							location := path
+							if location == "" {
+								location = "root"
+							}
+							*errors = append(*errors, fmt.Sprintf("Error at %s[%d]: %s", location, i, msg))
+						} else {
+							*errors = append(*errors, fmt.Sprintf("Error at %s[%d]: %v", path, i, e))
+						}
+					}
+				}
+			}
+		}
+
+		// Check for "error" key (single error)
+		if errVal, ok := v["error"]; ok && errVal != nil && scan {
+			switch errStr := errVal.(type) {
+			case string:
+				if errStr != "" {
+					location := path
+					if location == "" {
+						location = "root"
+					}
+					*errors = append(*errors, fmt.Sprintf("Error at %s: %s", location, errStr))
+				}
+			case map[string]interface{}:
+				if msg, ok := errStr["message"].(string); ok {
+					location := path
+					if location == "" {
+						location = "root"
+					}
+					*errors = append(*errors, fmt.Sprintf("Error at %s: %s", location, msg))
+				}
+			}
+		}
+
+		// Recursively check nested objects
+		for key, value := range v {
+			newPath := key
+			if path != "" {
+				newPath = path + "." + key
+			}
+			o.collectErrors(value, errors, newPath)
+		}
+
+	case []interface{}:
+		// Recursively check arrays
+		for i, item := range v {
+			newPath := fmt.Sprintf("%s[%d]", path, i)
+			if path == "" {
+				newPath = fmt.Sprintf("[%d]", i)
+			}
+			o.collectErrors(item, errors, newPath)
+		}
+	}
+}
+
+// arrayIndexPattern matches the array indices in a response path
+var arrayIndexPattern = regexp.MustCompile(`\[\d+\]`)
+
+// nestedErrorPathAllowed reports whether error keys in the object at path
+// should be reported, given NestedErrorPaths. Paths ignore array
+// indices and match the listed path or anything beneath it; "root" is the
+// top level of the response.
+func (o Options) nestedErrorPathAllowed(path string) bool {
+	if len(o.NestedErrorPaths) == 0 {
+		return true
+	}
+
+	path = arrayIndexPattern.ReplaceAllString(path, "")
+	if path == "" {
+		path = "root"
+	}
+
+	for _, allowed := range o.NestedErrorPaths {
+		if path == allowed || strings.HasPrefix(path, allowed+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package engine
+
+import "encoding/json"
+
+// TestResult represents the result of validating a single query
+type TestResult struct {
+	Name          string   `json:"name"`
+	Path          string   `json:"path"`
+	Passed        bool     `json:"passed"`
+	Target        string   `json:"target,omitempty"`
+	Role          string   `json:"role,omitempty"`
+	Skipped       bool     `json:"skipped,omitempty"`
+	Operation     string   `json:"operation,omitempty"`
+	OperationName string   `json:"operation_name,omitempty"`
+	SQL           string   `json:"sql,omitempty"`
+	Errors        []string `json:"errors,omitempty"`
+	Warnings      []string `json:"warnings,omitempty"`
+	Duration      int64    `json:"duration_ms"`
+
+	// CompileDuration and ExecuteDuration split the time GraphJin took into
+	// running the SQL and everything before it, compiling included
+	CompileDuration int64 `json:"compile_duration_ms"`
+	ExecuteDuration int64 `json:"execute_duration_ms"`
+
+	// ErrorDetails holds coded, machine-readable versions of Errors
+	ErrorDetails []ResultError `json:"error_details,omitempty"`
+
+	// Denied is set when a role outside the query's allowed-roles failed,
+	// as expected
+	Denied bool `json:"denied,omitempty"`
+
+	// UnexpectedPass is set when a query listed in the skip file passes
+	UnexpectedPass bool `json:"unexpected_pass,omitempty"`
+
+	// Data is the response data, kept for later scenario steps
+	Data json.RawMessage `json:"-"`
+}
+
+// TargetError is a database a run could not validate against
+type TargetError struct {
+	Target string `json:"target"`
+	Error  string `json:"error"`
+}
+
+// ValidationSummary represents the overall validation results
+type ValidationSummary struct {
+	Total            int          `json:"total"`
+	Passed           int          `json:"passed"`
+	Failed           int          `json:"failed"`
+	Skipped          int          `json:"skipped"`
+	UnexpectedPasses int          `json:"unexpected_passes"`
+	Warnings         int          `json:"warnings"`
+	Results          []TestResult `json:"results"`
+
+	// Inconsistent lists queries that pass on some databases and fail on
+	// others when validating with --all-databases
+	Inconsistent []string `json:"inconsistent,omitempty"`
+
+	// TargetErrors lists the databases skipped with --continue-on-config-error
+	// because they could not be connected to or set up
+	TargetErrors []TargetError `json:"target_errors,omitempty"`
+
+	// Regressions and Fixes list queries that newly failed or newly passed
+	// relative to the --baseline file
+	Regressions []string `json:"regressions,omitempty"`
+	Fixes       []string `json:"fixes,omitempty"`
+
+	// ErrorCategories counts failures' errors by category, set with
+	// --summary-by-category
+	ErrorCategories map[string]int `json:"error_categories,omitempty"`
+
+	// Seed is the --seed that reproduces random variable values, set when
+	// any were generated
+	Seed int64 `json:"seed,omitempty"`
+
+	// Interrupted is set when a signal stopped the run early; the results
+	// cover only the queries validated until then
+	Interrupted bool `json:"interrupted,omitempty"`
+
+	// StoppedEarly is set when --fail-fast or --max-failures ended the run
+	// before every query was validated
+	StoppedEarly bool `json:"stopped_early,omitempty"`
+}
@@ -0,0 +1,257 @@
+package engine
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/chirino/graphql/schema"
+)
+
+// Schema is what the relationship and deprecation checks know about the
+// database being validated. A nil Schema, or one whose foreign keys could
+// not be read, skips those checks.
+type Schema struct {
+	// Tables holds the columns of each table, to resolve query selections
+	// onto tables
+	Tables map[string][]string
+
+	// ForeignKeys holds the foreign keys read from the database or declared
+	// with related_to in the graphjin section
+	ForeignKeys []ForeignKey
+
+	// Aliases maps the graphjin section's table aliases onto the tables
+	// they select from
+	Aliases map[string]string
+
+	// Deprecated maps table and column names to the comment marking the
+	// column as deprecated
+	Deprecated map[string]map[string]string
+}
+
+// ForeignKey is a column referencing another table's column
+type ForeignKey struct {
+	Table, Column       string
+	RefTable, RefColumn string
+}
+
+// deprecatedCommentPattern matches column comments such as
+// "@deprecated use full_name" or "Deprecated: moving to profiles"
+var deprecatedCommentPattern = regexp.MustCompile(`(?i)@deprecated\b|^\s*deprecated\b`)
+
+// LoadRelationships reads the tables and foreign keys of the schema, adding
+// the aliases and related_to columns of the graphjin section
+func (s *Schema) LoadRelationships(db *sql.DB, config *Config) error {
+	tables, err := LoadTableColumns(db, config.Database.Schema)
+	if err != nil {
+		return err
+	}
+
+	rows, err := db.Query(`
+		SELECT kcu.table_name, kcu.column_name, ref.table_name, ref.column_name
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.key_column_usage kcu
+		  ON kcu.constraint_schema = tc.constraint_schema AND kcu.constraint_name = tc.constraint_name
+		JOIN information_schema.referential_constraints rc
+		  ON rc.constraint_schema = tc.constraint_schema AND rc.constraint_name = tc.constraint_name
+		JOIN information_schema.key_column_usage ref
+		  ON ref.constraint_schema = rc.unique_constraint_schema AND ref.constraint_name = rc.unique_constraint_name
+		 AND ref.ordinal_position = kcu.position_in_unique_constraint
+		WHERE tc.constraint_type = 'FOREIGN KEY' AND tc.table_schema = $1
+		ORDER BY kcu.table_name, kcu.column_name
+	`, config.Database.Schema)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	keys := []ForeignKey{}
+	for rows.Next() {
+		var fk ForeignKey
+		if err := rows.Scan(&fk.Table, &fk.Column, &fk.RefTable, &fk.RefColumn); err != nil {
+			return err
+		}
+		keys = append(keys, fk)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	aliases := make(map[string]string)
+	for _, t := range config.GraphJin.Tables {
+		table := t.Name
+		if t.Table != "" {
+			aliases[t.Name] = t.Table
+			table = t.Table
+		}
+		for _, c := range t.Columns {
+			// related_to is "table.column"
+			if ref, refColumn, ok := strings.Cut(c.ForeignKey, "."); ok {
+				keys = append(keys, ForeignKey{Table: table, Column: c.Name, RefTable: ref, RefColumn: refColumn})
+			}
+		}
+	}
+
+	s.Tables, s.ForeignKeys, s.Aliases = tables, keys, aliases
+	return nil
+}
+
+// LoadDeprecatedColumns reads the schema, unless LoadRelationships already
+// did, and the comments of its columns, keeping those marked as deprecated
+func (s *Schema) LoadDeprecatedColumns(db *sql.DB, schema string) error {
+	tables := s.Tables
+	if tables == nil {
+		var err error
+		if tables, err = LoadTableColumns(db, schema); err != nil {
+			return err
+		}
+	}
+
+	rows, err := db.Query(`
+		SELECT cl.relname, a.attname, d.description
+		FROM pg_catalog.pg_description d
+		JOIN pg_catalog.pg_class cl ON cl.oid = d.objoid
+		JOIN pg_catalog.pg_namespace n ON n.oid = cl.relnamespace
+		JOIN pg_catalog.pg_attribute a ON a.attrelid = cl.oid AND a.attnum = d.objsubid
+		WHERE n.nspname = $1 AND d.objsubid > 0`, schema)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	deprecated := make(map[string]map[string]string)
+	for rows.Next() {
+		var table, column, comment string
+		if err := rows.Scan(&table, &column, &comment); err != nil {
+			return err
+		}
+		if !deprecatedCommentPattern.MatchString(comment) {
+			continue
+		}
+		if deprecated[table] == nil {
+			deprecated[table] = make(map[string]string)
+		}
+		deprecated[table][column] = comment
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	s.Tables, s.Deprecated = tables, deprecated
+	return nil
+}
+
+// RelationshipErrors returns an error for each nested selection in query
+// whose table has no foreign key to its parent's table, either way or
+// through a join table. Selections that do not resolve to a table, such as
+// JSON columns, and those with a @through or @notRelated directive are not
+// checked.
+func (s *Schema) RelationshipErrors(query string) []string {
+	if s == nil || s.ForeignKeys == nil {
+		return nil
+	}
+	doc, err := ParseDocument(query)
+	if err != nil {
+		return nil
+	}
+
+	var errs []string
+	var walk func(sels schema.SelectionList, parent string)
+	walk = func(sels schema.SelectionList, parent string) {
+		for _, field := range SelectionFields(doc, sels) {
+			if len(field.Selections) == 0 || slices.Contains(s.Tables[parent], field.Name) {
+				continue
+			}
+
+			child := s.resolveTable(field.Name)
+			if parent != "" && child != "" && !skipsRelationshipCheck(field) && !s.tablesRelated(parent, child) {
+				msg := fmt.Sprintf("Relationship '%s' on table '%s' not found, no foreign key links %s and %s", field.Name, parent, parent, child)
+				if !slices.Contains(errs, msg) {
+					errs = append(errs, msg)
+				}
+			}
+			walk(field.Selections, child)
+		}
+	}
+	for _, op := range doc.Operations {
+		walk(op.Selections, "")
+	}
+	return errs
+}
+
+// resolveTable maps a selector name onto a table, through the graphjin
+// section's aliases first, or returns an empty string
+func (s *Schema) resolveTable(name string) string {
+	if table, ok := s.Aliases[TableFieldName(name)]; ok {
+		return table
+	}
+	return LookupTable(name, s.Tables)
+}
+
+// skipsRelationshipCheck reports whether a selection picks its relationship
+// itself, with GraphJin's @through or @notRelated directives
+func skipsRelationshipCheck(field *schema.FieldSelection) bool {
+	for _, d := range field.Directives {
+		switch d.Name {
+		case "through", "notRelated", "not_related":
+			return true
+		}
+	}
+	return false
+}
+
+// tablesRelated reports whether a foreign key links two tables, either way,
+// or a join table has foreign keys to both
+func (s *Schema) tablesRelated(a, b string) bool {
+	refs := make(map[string][]string)
+	for _, fk := range s.ForeignKeys {
+		if fk.Table == a && fk.RefTable == b || fk.Table == b && fk.RefTable == a {
+			return true
+		}
+		refs[fk.Table] = append(refs[fk.Table], fk.RefTable)
+	}
+
+	for _, targets := range refs {
+		i := slices.Index(targets, a)
+		if i < 0 {
+			continue
+		}
+		// A self-referencing join table needs two keys to the same table
+		if a == b && slices.Contains(targets[i+1:], b) || a != b && slices.Contains(targets, b) {
+			return true
+		}
+	}
+	return false
+}
+
+// DeprecationWarnings returns a warning for each deprecated column a query
+// selects
+func (s *Schema) DeprecationWarnings(query string) []string {
+	if s == nil || len(s.Deprecated) == 0 {
+		return nil
+	}
+
+	doc, err := ParseDocument(query)
+	if err != nil {
+		return nil
+	}
+
+	referenced := make(map[string]map[string]bool)
+	for _, op := range doc.Operations {
+		CollectColumnRefs(doc, op.Selections, "", s.Tables, referenced)
+	}
+
+	var warnings []string
+	for table, columns := range referenced {
+		for column := range columns {
+			if comment, ok := s.Deprecated[table][column]; ok {
+				warnings = append(warnings, fmt.Sprintf("Deprecated column %s.%s: %s", table, column, comment))
+			}
+		}
+	}
+	sort.Strings(warnings)
+	return warnings
+}
@@ -0,0 +1,72 @@
+package engine
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// RunSettings are the expectation and limits a query runs with
+type RunSettings struct {
+	Expect         string
+	Budget         time.Duration
+	MaxResultBytes int
+}
+
+// ResolveSettings applies a query's header settings for role over the
+// Options defaults, returning an error message for each invalid value
+func (o Options) ResolveSettings(header QueryHeader, role string) (RunSettings, []string) {
+	s := header.ForRole(role)
+	settings := RunSettings{
+		Expect:         s.Expect,
+		Budget:         o.Budget,
+		MaxResultBytes: o.MaxResultBytes,
+	}
+	if settings.Expect == "" && o.RequireNonEmpty {
+		settings.Expect = ExpectNonEmpty
+	}
+
+	var errs []string
+	if s.Budget != "" {
+		budget, err := time.ParseDuration(s.Budget)
+		if err != nil || budget <= 0 {
+			errs = append(errs, fmt.Sprintf("Invalid budget header: %q is not a positive duration such as 500ms", s.Budget))
+		} else {
+			settings.Budget = budget
+		}
+	}
+	if s.MaxResultBytes != "" {
+		limit, err := strconv.Atoi(s.MaxResultBytes)
+		if err != nil || limit < 0 {
+			errs = append(errs, fmt.Sprintf("Invalid max-result-bytes header: %q is not a number of bytes", s.MaxResultBytes))
+		} else {
+			settings.MaxResultBytes = limit
+		}
+	}
+	return settings, errs
+}
+
+// BudgetError describes a query that ran past its budget
+func BudgetError(elapsed, budget time.Duration) string {
+	return fmt.Sprintf("Budget exceeded: the query took %v, over its %v budget", elapsed.Round(time.Millisecond), budget)
+}
+
+// CheckResultSize returns an error message when data exceeds limit bytes,
+// or an empty string when it fits or limit is 0
+func CheckResultSize(data []byte, limit int) string {
+	if limit <= 0 || len(data) <= limit {
+		return ""
+	}
+	return fmt.Sprintf("Result too large: %d bytes exceeds the limit of %d, add a limit to the query (e.g. users(limit: 100))",
+		len(data), limit)
+}
+
+// checkSQLStatements returns a warning when a query ran more SQL statements
+// than MaxSQLStatements allows, or an empty string
+func (o Options) checkSQLStatements(statements int) string {
+	if statements <= o.MaxSQLStatements {
+		return ""
+	}
+	return fmt.Sprintf("Potential N+1: the query ran %d SQL statements, more than --max-sql-statements %d",
+		statements, o.MaxSQLStatements)
+}
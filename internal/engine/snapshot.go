@@ -0,0 +1,163 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/chirino/graphql/schema"
+)
+
+// schemaSnapshot is the JSON form of a schema snapshot
+type schemaSnapshot struct {
+	Tables map[string][]string `json:"tables"`
+}
+
+// aggregatePrefixes are the prefixes GraphJin accepts in front of a column
+// name to select an aggregate of it, e.g. count_id
+var aggregatePrefixes = []string{
+	"count_", "sum_", "avg_", "max_", "min_",
+	"stddev_pop_", "stddev_samp_", "stddev_", "var_pop_", "var_samp_", "variance_",
+}
+
+// LoadSchemaFile reads a schema snapshot, either JSON of the form
+// {"tables": {"users": ["id", "email"]}} or a CSV export of
+// information_schema.columns with table_name and column_name columns. Rows
+// of a CSV export that has a table_schema column are limited to dbSchema.
+func LoadSchemaFile(path, dbSchema string) (map[string][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema file: %w", err)
+	}
+
+	var tables map[string][]string
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		var snapshot schemaSnapshot
+		if err := json.Unmarshal(data, &snapshot); err != nil {
+			return nil, fmt.Errorf("failed to parse schema file %s: %w", path, err)
+		}
+		tables = snapshot.Tables
+	} else {
+		tables, err = parseColumnsCSV(data, dbSchema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse schema file %s: %w", path, err)
+		}
+	}
+
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("schema file %s defines no tables", path)
+	}
+	return tables, nil
+}
+
+// parseColumnsCSV reads the tables and columns of an information_schema.columns
+// export, locating the columns it needs by the header row
+func parseColumnsCSV(data []byte, dbSchema string) (map[string][]string, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, UTF8BOM)))
+	r.FieldsPerRecord = -1
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, err
+	}
+	schemaCol := slices.Index(header, "table_schema")
+	tableCol := slices.Index(header, "table_name")
+	columnCol := slices.Index(header, "column_name")
+	if tableCol < 0 || columnCol < 0 {
+		return nil, fmt.Errorf("expected a header row with table_name and column_name columns")
+	}
+	if dbSchema == "" {
+		dbSchema = "public"
+	}
+
+	tables := make(map[string][]string)
+	for {
+		record, err := r.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		if len(record) <= tableCol || len(record) <= columnCol {
+			continue
+		}
+		if schemaCol >= 0 && schemaCol < len(record) && record[schemaCol] != dbSchema {
+			continue
+		}
+		table := record[tableCol]
+		tables[table] = append(tables[table], record[columnCol])
+	}
+	return tables, nil
+}
+
+// checkSchemaSnapshot checks the tables and columns a query selects against
+// a schema snapshot, returning the first problem found the way GraphJin
+// reports the first one when compiling
+func checkSchemaSnapshot(snapshot map[string][]string, query string) error {
+	doc, err := ParseDocument(query)
+	if err != nil {
+		return err
+	}
+
+	for _, op := range doc.Operations {
+		for _, field := range SelectionFields(doc, op.Selections) {
+			if strings.HasPrefix(field.Name, "__") {
+				continue
+			}
+			table := LookupTable(strings.TrimSuffix(field.Name, "_cursor"), snapshot)
+			if table == "" {
+				return fmt.Errorf("table not found in schema snapshot: %s", field.Name)
+			}
+			if err := checkSnapshotSelections(doc, snapshot, field.Selections, table); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkSnapshotSelections checks the fields selected from table: leaf
+// fields must be its columns or aggregates of them, nested selections
+// related tables or JSON columns
+func checkSnapshotSelections(doc *schema.QueryDocument, snapshot map[string][]string, sels schema.SelectionList, table string) error {
+	columns := snapshot[table]
+	for _, field := range SelectionFields(doc, sels) {
+		if strings.HasPrefix(field.Name, "__") || slices.Contains(columns, field.Name) {
+			continue
+		}
+
+		if len(field.Selections) > 0 {
+			child := LookupTable(field.Name, snapshot)
+			if child == "" {
+				return fmt.Errorf("table not found in schema snapshot: %s (selected in %s)", field.Name, table)
+			}
+			if err := checkSnapshotSelections(doc, snapshot, field.Selections, child); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !isAggregateColumn(field.Name, columns) {
+			return fmt.Errorf("column not found in schema snapshot: %s.%s", table, field.Name)
+		}
+	}
+	return nil
+}
+
+// isAggregateColumn reports whether name selects an aggregate of one of
+// columns, e.g. count_id or max_price
+func isAggregateColumn(name string, columns []string) bool {
+	for _, prefix := range aggregatePrefixes {
+		if column, ok := strings.CutPrefix(name, prefix); ok && slices.Contains(columns, column) {
+			return true
+		}
+	}
+	return false
+}
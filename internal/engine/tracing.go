@@ -0,0 +1,45 @@
+package engine
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// noopTracer stands in for Options.Tracer when it is nil
+var noopTracer = trace.NewNoopTracerProvider().Tracer("")
+
+// startQuerySpan starts the span of a single query validation
+func (o Options) startQuerySpan(ctx context.Context, input QueryInput) (context.Context, trace.Span) {
+	tracer := o.Tracer
+	if tracer == nil {
+		tracer = noopTracer
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("gql.query.name", input.Name),
+		attribute.String("gql.query.path", input.Path),
+	}
+	if input.Role != "" {
+		attrs = append(attrs, attribute.String("gql.role", input.Role))
+	}
+	return tracer.Start(ctx, "validate "+input.Name, trace.WithAttributes(attrs...))
+}
+
+// endQuerySpan records a query's outcome on its span and ends it
+func (o Options) endQuerySpan(span trace.Span, result TestResult) {
+	span.SetAttributes(
+		attribute.String("gql.operation", result.Operation),
+		attribute.Int64("gql.duration_ms", result.Duration),
+		attribute.Bool("gql.passed", result.Passed),
+	)
+	if !result.Passed {
+		span.SetAttributes(attribute.StringSlice("gql.error_categories", ErrorCategories(result.Errors)))
+		if len(result.Errors) > 0 {
+			span.SetStatus(codes.Error, Redact(result.Errors[0], o.Secrets))
+		}
+	}
+	span.End()
+}
@@ -0,0 +1,444 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
+
+	graphjin "github.com/dosco/graphjin/core"
+)
+
+// QueryInput is a query to validate along with its variables, read from a
+// query file or defined inline, such as in a manifest
+type QueryInput struct {
+	Name      string
+	Path      string
+	Query     string
+	Variables json.RawMessage
+	// Role is the GraphJin role the query is run as, if any
+	Role string
+	// Operation names the operation to run in a multi-operation document
+	Operation string
+	// Source maps the query's lines back to the files they were read from,
+	// for reporting positions; nil when Path holds the query as is
+	Source SourceMap
+}
+
+// ValidateFile loads a query file and its companion variables, then
+// validates it as role, or DefaultRole when role is empty. A panic becomes
+// a failed result. ctx carries the run's random source, see WithRandom.
+// gj may be nil when checking against a Snapshot.
+func (o Options) ValidateFile(ctx context.Context, gj *graphjin.GraphJin, path, role string) TestResult {
+	return o.Recover(filepath.Base(path), path, func() TestResult {
+		return o.validateFile(ctx, gj, path, role)
+	})
+}
+
+// ValidateQuery is ValidateFile for an in-memory query
+func (o Options) ValidateQuery(ctx context.Context, gj *graphjin.GraphJin, input QueryInput) TestResult {
+	return o.Recover(input.Name, input.Path, func() TestResult {
+		return o.validateQuery(ctx, gj, input)
+	})
+}
+
+// Recover runs a validation, turning a panic into a failed result for the
+// named query, and attaches the structured form of its errors with Secrets
+// masked
+func (o Options) Recover(name, path string, validate func() TestResult) TestResult {
+	result := recoverPanic(name, path, validate)
+	for i, msg := range result.Errors {
+		result.Errors[i] = Redact(msg, o.Secrets)
+	}
+	result.ErrorDetails = StructuredErrors(result.Errors)
+	return result
+}
+
+func (o Options) validateFile(ctx context.Context, gj *graphjin.GraphJin, path, role string) (result TestResult) {
+	result = TestResult{
+		Name:   filepath.Base(path),
+		Path:   path,
+		Passed: false,
+		Errors: []string{},
+	}
+
+	start := time.Now()
+
+	// Read query file, splicing in any included partials
+	query, source, err := o.ReadQueryFile(path)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("Failed to read query file: %v", err))
+		result.Duration = time.Since(start).Milliseconds()
+		return result
+	}
+
+	// Enforce the documentation standard however validation ends
+	if o.RequireDescription && ParseHeader(query).Description == "" {
+		defer func() {
+			result.Errors = append([]string{MissingDescriptionError}, result.Errors...)
+			result.Passed = false
+		}()
+	}
+
+	// Load variables from the companion JSON file, if any
+	variables, varsFile, err := o.LoadVariables(ctx, path)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("Failed to load variables: %v", err))
+		result.Duration = time.Since(start).Milliseconds()
+		return result
+	}
+
+	if varsFile != "" {
+		o.debugf("  Using variables from: %s", filepath.Base(varsFile))
+	}
+
+	// Check variables against the companion JSON Schema, if any
+	violations, err := o.ValidateVariablesSchema(path, variables)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("Failed to validate variables: %v", err))
+		result.Duration = time.Since(start).Milliseconds()
+		return result
+	}
+	if len(violations) > 0 {
+		for _, v := range violations {
+			result.Errors = append(result.Errors, fmt.Sprintf("Variables schema violation at %s", v))
+		}
+		result.Duration = time.Since(start).Milliseconds()
+		return result
+	}
+
+	return o.validateQuery(ctx, gj, QueryInput{
+		Name:      result.Name,
+		Path:      path,
+		Query:     query,
+		Variables: variables,
+		Role:      role,
+		Operation: o.Operation,
+		Source:    source,
+	})
+}
+
+// validateQuery compiles and runs an in-memory query against GraphJin.
+// When the variables of a multi-operation document are keyed by operation
+// name, the selected operation runs with its own variables, or every
+// operation does when none is selected.
+func (o Options) validateQuery(ctx context.Context, gj *graphjin.GraphJin, input QueryInput) TestResult {
+	perOp, names, ok := SplitOperationVariables(input.Query, input.Variables)
+	if !ok {
+		return o.validateOperation(ctx, gj, input)
+	}
+	if input.Operation != "" {
+		input.Variables = perOp[input.Operation]
+		return o.validateOperation(ctx, gj, input)
+	}
+	return o.validateEachOperation(ctx, gj, input, names, perOp)
+}
+
+// validateEachOperation runs every operation of a document with its own
+// variables, combining the outcomes into one result. Errors and warnings
+// are prefixed with the operation's name, unless every operation reported
+// them, as with problems in the document's header.
+func (o Options) validateEachOperation(ctx context.Context, gj *graphjin.GraphJin, input QueryInput, names []string, perOp map[string]json.RawMessage) TestResult {
+	result := TestResult{
+		Name:   input.Name,
+		Path:   input.Path,
+		Passed: true,
+	}
+
+	var types []string
+	var sqls []string
+	var errs, warnings [][]string
+	for _, name := range names {
+		opInput := input
+		opInput.Operation = name
+		opInput.Variables = perOp[name]
+		opResult := o.validateOperation(ctx, gj, opInput)
+
+		result.Passed = result.Passed && opResult.Passed
+		result.Duration += opResult.Duration
+		result.CompileDuration += opResult.CompileDuration
+		result.ExecuteDuration += opResult.ExecuteDuration
+		errs = append(errs, opResult.Errors)
+		warnings = append(warnings, opResult.Warnings)
+		if opResult.SQL != "" {
+			sqls = append(sqls, fmt.Sprintf("-- %s\n%s", name, opResult.SQL))
+		}
+		if opResult.Operation != "" && !slices.Contains(types, opResult.Operation) {
+			types = append(types, opResult.Operation)
+		}
+	}
+
+	result.Errors = mergeOperationMessages(names, errs)
+	result.Warnings = mergeOperationMessages(names, warnings)
+	result.Operation = strings.Join(types, ",")
+	result.OperationName = strings.Join(names, ", ")
+	result.SQL = strings.Join(sqls, "\n")
+	return result
+}
+
+// mergeOperationMessages combines the messages each operation reported,
+// prefixing them with "[operation] " unless all operations reported them
+func mergeOperationMessages(names []string, messages [][]string) []string {
+	// Count how many operations reported each message
+	counts := make(map[string]int)
+	for _, msgs := range messages {
+		seen := make(map[string]bool)
+		for _, msg := range msgs {
+			if !seen[msg] {
+				seen[msg] = true
+				counts[msg]++
+			}
+		}
+	}
+
+	merged := []string{}
+	shared := make(map[string]bool)
+	for i, msgs := range messages {
+		for _, msg := range msgs {
+			switch {
+			case counts[msg] < len(names):
+				merged = append(merged, fmt.Sprintf("[%s] %s", names[i], msg))
+			case !shared[msg]:
+				shared[msg] = true
+				merged = append(merged, msg)
+			}
+		}
+	}
+	return merged
+}
+
+// validateOperation compiles and runs a single operation against GraphJin
+func (o Options) validateOperation(ctx context.Context, gj *graphjin.GraphJin, input QueryInput) TestResult {
+	result := TestResult{
+		Name:   input.Name,
+		Path:   input.Path,
+		Passed: false,
+		Errors: []string{},
+	}
+
+	ctx, span := o.startQuerySpan(ctx, input)
+	defer func() { o.endQuerySpan(span, result) }()
+
+	start := time.Now()
+	variables := input.Variables
+	if len(variables) == 0 {
+		variables = json.RawMessage("{}")
+	}
+
+	queryText, err := SelectOperation(input.Query, input.Operation)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		result.Duration = time.Since(start).Milliseconds()
+		return result
+	}
+	queryText = AppendFragments(queryText, o.Fragments)
+
+	var opType graphjin.OpType
+	if h, err := graphjin.Operation(queryText); err == nil {
+		opType = h.Type
+		result.Operation = OperationTypeName(opType)
+		result.OperationName = DisplayOperationName(h.Name)
+	}
+
+	// Catch typos GraphJin would ignore or report without a position
+	if msg := o.UnknownDirectiveError(input.Path, input.Query, input.Source); msg != "" {
+		result.Errors = append(result.Errors, msg)
+		result.Duration = time.Since(start).Milliseconds()
+		return result
+	}
+
+	// Name the tables of nested selections no foreign key links, which
+	// GraphJin reports as a missing table at best
+	if errs := o.Schema.RelationshipErrors(queryText); len(errs) > 0 {
+		result.Errors = append(result.Errors, errs...)
+		result.Duration = time.Since(start).Milliseconds()
+		return result
+	}
+
+	header := ParseHeader(input.Query)
+	settings, settingErrs := o.ResolveSettings(header, input.Role)
+
+	// Execute query; subscriptions are only compiled, never streamed
+	ctx = o.withQueryUser(ctx, input.Role)
+	if settings.Budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, settings.Budget)
+		defer cancel()
+	}
+
+	ctx, phases := withQueryPhases(ctx)
+	callStart := time.Now()
+
+	var res *graphjin.Result
+	if o.Snapshot != nil {
+		err = checkSchemaSnapshot(o.Snapshot, queryText)
+	} else if opType == graphjin.OpSubscription {
+		err = CompileSubscription(ctx, gj, queryText, variables)
+	} else {
+		res, err = gj.GraphQL(ctx, queryText, variables, nil)
+	}
+
+	elapsed := time.Since(start)
+	result.Duration = elapsed.Milliseconds()
+	execute := phases.executed()
+	result.ExecuteDuration = execute.Milliseconds()
+	result.CompileDuration = (time.Since(callStart) - execute).Milliseconds()
+
+	if o.ShowSQL && res != nil {
+		result.SQL = res.SQL()
+	}
+
+	// Check for execution errors, pointing syntax errors at their position
+	if err != nil {
+		if settings.Budget > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			result.Errors = append(result.Errors, BudgetError(elapsed, settings.Budget))
+		} else if msg := PositionedSyntaxError(input.Path, input.Query, input.Source); msg != "" {
+			result.Errors = append(result.Errors, msg)
+		} else if o.Snapshot != nil {
+			result.Errors = append(result.Errors, err.Error())
+		} else {
+			result.Errors = append(result.Errors, fmt.Sprintf("Execution error: %v", err))
+		}
+	} else if settings.Budget > 0 && elapsed > settings.Budget {
+		result.Errors = append(result.Errors, BudgetError(elapsed, settings.Budget))
+	}
+
+	// Oversized responses are not scanned or checked further
+	var sizeError string
+	if res != nil {
+		if sizeError = CheckResultSize(res.Data, settings.MaxResultBytes); sizeError != "" {
+			res.Data = nil
+		}
+	}
+
+	// Check for GraphQL errors and nested errors in the response, keeping
+	// warnings apart
+	if res != nil {
+		errs, warnings := o.ResponseErrors(res)
+		result.Errors = append(result.Errors, errs...)
+		result.Warnings = warnings
+	}
+
+	// Flag read queries that ran many SQL statements
+	if o.MaxSQLStatements > 0 && res != nil && opType == graphjin.OpQuery {
+		if msg := o.checkSQLStatements(phases.statementCount()); msg != "" {
+			if o.Strict {
+				result.Errors = append(result.Errors, msg)
+			} else {
+				result.Warnings = append(result.Warnings, msg)
+			}
+		}
+	}
+
+	if sizeError != "" {
+		result.Errors = append(result.Errors, sizeError)
+	}
+
+	// Check the expected emptiness of the result, once it ran cleanly
+	result.Warnings = append(result.Warnings, header.Warnings()...)
+	errs, warnings := o.SplitWarnings(o.Schema.DeprecationWarnings(queryText))
+	result.Errors = append(result.Errors, errs...)
+	result.Errors = append(result.Errors, settingErrs...)
+	result.Warnings = append(result.Warnings, warnings...)
+
+	if settings.Expect != "" && len(result.Errors) == 0 && res != nil {
+		assertions, err := CheckExpectation(settings.Expect, res.Data)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("Invalid expect header: %v", err))
+		}
+		result.Errors = append(result.Errors, assertions...)
+	}
+
+	result.Errors = DedupeErrors(result.Errors)
+
+	// Query passes only if there are no errors at any level
+	if len(result.Errors) == 0 {
+		result.Passed = true
+	}
+	if res != nil {
+		result.Data = res.Data
+	}
+
+	return result
+}
+
+// maxPanicStackLines limits how much of a recovered panic's stack is reported
+const maxPanicStackLines = 20
+
+// recoverPanic runs a validation, turning a panic into a failed result for
+// the named query
+func recoverPanic(name, path string, validate func() TestResult) (result TestResult) {
+	start := time.Now()
+
+	defer func() {
+		if r := recover(); r != nil {
+			result = TestResult{
+				Name:     name,
+				Path:     path,
+				Errors:   []string{fmt.Sprintf("Panic during validation: %v\n%s", r, truncatedStack(maxPanicStackLines))},
+				Duration: time.Since(start).Milliseconds(),
+			}
+		}
+	}()
+
+	return validate()
+}
+
+// truncatedStack returns the current goroutine's stack limited to maxLines lines
+func truncatedStack(maxLines int) string {
+	lines := strings.Split(strings.TrimSpace(string(debug.Stack())), "\n")
+	if len(lines) > maxLines {
+		lines = append(lines[:maxLines], "...")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// CompileSubscription validates a subscription through GraphJin's
+// subscription path and unsubscribes immediately so nothing is streamed
+func CompileSubscription(ctx context.Context, gj *graphjin.GraphJin, query string, variables json.RawMessage) error {
+	m, err := gj.Subscribe(ctx, query, variables, nil)
+	if err != nil {
+		return err
+	}
+	m.Unsubscribe()
+	return nil
+}
+
+// OperationTypeName returns the GraphQL keyword for an operation type
+func OperationTypeName(t graphjin.OpType) string {
+	switch t {
+	case graphjin.OpQuery:
+		return "query"
+	case graphjin.OpMutation:
+		return "mutation"
+	case graphjin.OpSubscription:
+		return "subscription"
+	default:
+		return "unknown"
+	}
+}
+
+// withQueryUser runs a query as role, or as DefaultRole when role is empty,
+// and as UserID
+func (o Options) withQueryUser(ctx context.Context, role string) context.Context {
+	if role == "" {
+		role = o.DefaultRole
+	}
+	return WithQueryUser(ctx, role, o.UserID)
+}
+
+// WithQueryUser runs queries as role and userID, each when not empty
+func WithQueryUser(ctx context.Context, role, userID string) context.Context {
+	if role != "" {
+		ctx = context.WithValue(ctx, graphjin.UserRoleKey, role)
+	}
+	if userID != "" {
+		ctx = context.WithValue(ctx, graphjin.UserIDKey, userID)
+	}
+	return ctx
+}
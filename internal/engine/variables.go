@@ -0,0 +1,347 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"gopkg.in/yaml.v2"
+)
+
+// envVarPattern matches ${VAR_NAME} references in variables files
+var envVarPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// variableFuncs are the template functions available inside variables files
+var variableFuncs = template.FuncMap{
+	"now": func() string {
+		return time.Now().UTC().Format(time.RFC3339)
+	},
+	"today": func() string {
+		return time.Now().UTC().Format("2006-01-02")
+	},
+	"unix": func() int64 {
+		return time.Now().Unix()
+	},
+	"env": os.Getenv,
+}
+
+// ExpandVariables renders {{...}} template actions (e.g. {{now}}) in the raw
+// contents of the variables at key, drawing random values from ctx's run
+func ExpandVariables(ctx context.Context, key string, data []byte) ([]byte, error) {
+	tmpl, err := template.New("variables").Funcs(variableFuncs).Funcs(runRandom(ctx).funcs(key)).Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, nil); err != nil {
+		return nil, fmt.Errorf("could not expand template: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// SubstituteEnvJSON replaces ${ENV_VAR} references in the string values of
+// JSON variables. The JSON is returned as is when it has no references.
+func SubstituteEnvJSON(data []byte) ([]byte, error) {
+	if !envVarPattern.Match(data) {
+		return data, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var vars interface{}
+	if err := decoder.Decode(&vars); err != nil {
+		return nil, fmt.Errorf("could not parse variables: %w", err)
+	}
+
+	vars, err := SubstituteEnv(vars)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(vars)
+}
+
+// SubstituteEnv replaces ${ENV_VAR} references in the string values of
+// parsed variables, so the environment's values are never read as JSON or
+// YAML syntax. A reference to a variable that is not set is an error.
+func SubstituteEnv(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		var unset []string
+		expanded := envVarPattern.ReplaceAllStringFunc(v, func(match string) string {
+			name := envVarPattern.FindStringSubmatch(match)[1]
+			value, ok := os.LookupEnv(name)
+			if !ok {
+				unset = append(unset, name)
+			}
+			return value
+		})
+		if len(unset) > 0 {
+			return nil, fmt.Errorf("environment variable %s is not set", strings.Join(unset, ", "))
+		}
+		return expanded, nil
+	case map[string]interface{}:
+		for key, value := range v {
+			expanded, err := SubstituteEnv(value)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = expanded
+		}
+		return v, nil
+	case []interface{}:
+		for i, value := range v {
+			expanded, err := SubstituteEnv(value)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = expanded
+		}
+		return v, nil
+	default:
+		return v, nil
+	}
+}
+
+// VariablesYAMLSuffix is the suffix of YAML variables files, an alternative
+// to the companion .json file
+const VariablesYAMLSuffix = ".vars.yaml"
+
+// FindVariablesFile returns the companion variables file of a query, or an
+// empty string if there is none. A .json file is preferred over a
+// .vars.yaml file.
+func (o Options) FindVariablesFile(queryPath string) string {
+	base := o.QueryBasePath(queryPath)
+	for _, candidate := range []string{base + ".json", base + VariablesYAMLSuffix} {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// FindVariablesOverride returns the Env override file of a query, or an
+// empty string if there is none
+func (o Options) FindVariablesOverride(queryPath string) string {
+	if o.Env == "" {
+		return ""
+	}
+
+	base := o.QueryBasePath(queryPath) + "." + o.Env
+	for _, candidate := range []string{base + ".json", base + VariablesYAMLSuffix} {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// LoadVariables reads the JSON or YAML variables file that accompanies a
+// query file, deep-merging the Env override file over it. It returns the
+// expanded variables as JSON and the path they were loaded from, or empty
+// variables and an empty path when no companion file exists.
+func (o Options) LoadVariables(ctx context.Context, queryPath string) (json.RawMessage, string, error) {
+	vars := json.RawMessage("{}")
+
+	varsFile := o.FindVariablesFile(queryPath)
+	if varsFile != "" && !strings.HasSuffix(varsFile, VariablesYAMLSuffix) {
+		if _, err := os.Stat(o.QueryBasePath(queryPath) + VariablesYAMLSuffix); err == nil {
+			o.warnf("Both %s and a %s file exist, using the JSON file", varsFile, VariablesYAMLSuffix)
+		}
+	}
+	if varsFile != "" {
+		var err error
+		vars, err = ReadVariablesFile(ctx, varsFile)
+		if err != nil {
+			return nil, varsFile, err
+		}
+	}
+
+	overrideFile := o.FindVariablesOverride(queryPath)
+	if overrideFile == "" {
+		return vars, varsFile, nil
+	}
+
+	override, err := ReadVariablesFile(ctx, overrideFile)
+	if err != nil {
+		return nil, overrideFile, err
+	}
+	merged, err := MergeVariables(vars, override)
+	if err != nil {
+		return nil, overrideFile, fmt.Errorf("could not merge %s: %w", overrideFile, err)
+	}
+
+	o.debugf("  Merged %s variables from: %s", o.Env, overrideFile)
+
+	if varsFile == "" {
+		varsFile = overrideFile
+	}
+	return merged, varsFile, nil
+}
+
+// ReadVariablesFile reads and expands a JSON or YAML variables file,
+// returning the variables as JSON
+func ReadVariablesFile(ctx context.Context, varsFile string) (json.RawMessage, error) {
+	data, err := os.ReadFile(varsFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not read variables file: %w", err)
+	}
+
+	data, err = ExpandVariables(ctx, varsFile, data)
+	if err != nil {
+		return nil, fmt.Errorf("could not expand variables file: %w", err)
+	}
+
+	if !strings.HasSuffix(varsFile, VariablesYAMLSuffix) {
+		data, err = SubstituteEnvJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("could not expand variables file: %w", err)
+		}
+		return json.RawMessage(data), nil
+	}
+
+	var vars map[string]interface{}
+	if err := yaml.Unmarshal(data, &vars); err != nil {
+		return nil, fmt.Errorf("could not parse variables file: %w", err)
+	}
+	if vars == nil {
+		return json.RawMessage("{}"), nil
+	}
+
+	expanded, err := SubstituteEnv(JSONCompatible(vars))
+	if err != nil {
+		return nil, fmt.Errorf("could not expand variables file: %w", err)
+	}
+
+	jsonData, err := json.Marshal(expanded)
+	if err != nil {
+		return nil, fmt.Errorf("could not convert variables file to JSON: %w", err)
+	}
+
+	return json.RawMessage(jsonData), nil
+}
+
+// MergeVariables deep-merges override over base. Nested objects are merged
+// key by key; any other override value, including a list, replaces the
+// base value.
+func MergeVariables(base, override json.RawMessage) (json.RawMessage, error) {
+	var baseVars, overrideVars map[string]interface{}
+	if err := json.Unmarshal(base, &baseVars); err != nil {
+		return nil, fmt.Errorf("base variables must be a JSON object: %w", err)
+	}
+	if err := json.Unmarshal(override, &overrideVars); err != nil {
+		return nil, fmt.Errorf("override variables must be a JSON object: %w", err)
+	}
+
+	merged, err := json.Marshal(deepMerge(baseVars, overrideVars))
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(merged), nil
+}
+
+func deepMerge(base, override map[string]interface{}) map[string]interface{} {
+	if base == nil {
+		base = make(map[string]interface{}, len(override))
+	}
+	for key, value := range override {
+		baseMap, baseIsMap := base[key].(map[string]interface{})
+		overrideMap, overrideIsMap := value.(map[string]interface{})
+		if baseIsMap && overrideIsMap {
+			base[key] = deepMerge(baseMap, overrideMap)
+		} else {
+			base[key] = value
+		}
+	}
+	return base
+}
+
+// ValidateVariablesSchema checks variables against the JSON Schema in the
+// query's companion .schema.json file, if one exists. It returns one message
+// per schema violation.
+func (o Options) ValidateVariablesSchema(queryPath string, variables json.RawMessage) ([]string, error) {
+	schemaFile := o.QueryBasePath(queryPath) + ".schema.json"
+
+	if _, err := os.Stat(schemaFile); err != nil {
+		return nil, nil
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.AssertFormat = true
+
+	schema, err := compiler.Compile(schemaFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not compile variables schema: %w", err)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(variables, &value); err != nil {
+		return nil, fmt.Errorf("could not parse variables: %w", err)
+	}
+
+	err = schema.Validate(value)
+	if err == nil {
+		return nil, nil
+	}
+
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil, err
+	}
+
+	var violations []string
+	collectSchemaViolations(validationErr, &violations)
+	return violations, nil
+}
+
+// collectSchemaViolations gathers the leaf causes of a validation error,
+// which carry the specific messages
+func collectSchemaViolations(err *jsonschema.ValidationError, violations *[]string) {
+	if len(err.Causes) == 0 {
+		location := err.InstanceLocation
+		if location == "" {
+			location = "/"
+		}
+		*violations = append(*violations, fmt.Sprintf("%s: %s", location, err.Message))
+		return
+	}
+
+	for _, cause := range err.Causes {
+		collectSchemaViolations(cause, violations)
+	}
+}
+
+// JSONCompatible converts the map[interface{}]interface{} values produced by
+// the YAML decoder into map[string]interface{} so they can be marshaled
+func JSONCompatible(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[fmt.Sprint(key)] = JSONCompatible(value)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[key] = JSONCompatible(value)
+		}
+		return m
+	case []interface{}:
+		for i, value := range v {
+			v[i] = JSONCompatible(value)
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,155 @@
+// Package validator embeds gql-validate's query validation in other Go
+// programs, such as a project's own go test suite:
+//
+//	cfg, err := validator.LoadConfig("config.yaml")
+//	...
+//	v, err := validator.New(cfg)
+//	...
+//	defer v.Close()
+//
+//	summary, err := v.ValidateDir("./queries")
+//	if summary.Failed > 0 {
+//		t.Errorf("%d queries failed validation", summary.Failed)
+//	}
+//
+// Queries go through the same checks as gql-validate validate, with the
+// CLI's default flags and the config's validate and graphjin sections, so
+// both report the same results. A Validator is not safe for concurrent use.
+package validator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"time"
+
+	"graphql-validation-tool/internal/engine"
+
+	graphjin "github.com/dosco/graphjin/core"
+)
+
+type (
+	// Config is the gql-validate configuration, as read from config.yaml
+	Config = engine.Config
+
+	// TestResult is the result of validating a single query
+	TestResult = engine.TestResult
+
+	// ValidationSummary is the result of validating a set of queries
+	ValidationSummary = engine.ValidationSummary
+)
+
+// LoadConfig reads a config file, applying the same environment variable
+// overrides as the CLI
+func LoadConfig(path string) (*Config, error) {
+	return engine.LoadConfig(path)
+}
+
+// ParseConfig parses a config read from r, applying the same environment
+// variable overrides as the CLI
+func ParseConfig(r io.Reader) (*Config, error) {
+	return engine.ParseConfig(r)
+}
+
+// Validator validates query files against a database
+type Validator struct {
+	gj   *graphjin.GraphJin
+	db   *sql.DB
+	opts engine.Options
+
+	// ctx carries the random source of variables templates
+	ctx  context.Context
+	seed int64
+}
+
+// New connects to the configured database and prepares GraphJin. Call
+// Close when done.
+func New(cfg *Config) (*Validator, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	db, err := engine.Connect(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	gj, err := engine.NewGraphJin(cfg, db)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize GraphJin: %w", err)
+	}
+	engine.WarmUp(gj)
+
+	// As in the CLI, the relationship check is skipped when the foreign
+	// keys cannot be read
+	schema := &engine.Schema{}
+	_ = schema.LoadRelationships(db, cfg)
+
+	v := &Validator{
+		gj: gj,
+		db: db,
+		opts: engine.Options{
+			Extensions:        engine.NormalizeExtensions(cfg.Queries.Extensions),
+			AllowedDirectives: cfg.Queries.AllowedDirectives,
+			DefaultRole:       cfg.GraphJin.DefaultRole,
+			UserID:            cfg.GraphJin.UserID,
+			Schema:            schema,
+			Secrets:           engine.Secrets(cfg.Database),
+		},
+		seed: time.Now().UnixNano(),
+	}
+	if cfg.Queries.Dir != "" {
+		v.opts.QueryDirs = []string{cfg.Queries.Dir}
+	}
+	v.ctx = engine.WithRandom(context.Background(), v.seed)
+
+	return v, nil
+}
+
+// ValidateFile validates a single query file, using its companion variables
+// file if there is one
+func (v *Validator) ValidateFile(path string) TestResult {
+	return v.opts.ValidateFile(v.ctx, v.gj, path, "")
+}
+
+// ValidateDir validates every query file under dir, leaving out partials
+// that other files include
+func (v *Validator) ValidateDir(dir string) (ValidationSummary, error) {
+	files, err := v.opts.FindQueryFiles(dir)
+	if err != nil {
+		return ValidationSummary{}, fmt.Errorf("failed to find query files: %w", err)
+	}
+	files = v.opts.ExcludePartialFiles(files)
+
+	summary := ValidationSummary{
+		Total:   len(files),
+		Results: make([]TestResult, 0, len(files)),
+	}
+	for _, path := range files {
+		result := v.ValidateFile(path)
+		switch {
+		case result.Skipped:
+			summary.Skipped++
+		case result.Passed:
+			summary.Passed++
+		default:
+			summary.Failed++
+		}
+		summary.Warnings += len(result.Warnings)
+		summary.Results = append(summary.Results, result)
+	}
+
+	// The seed reproduces the random values of variables templates
+	if engine.RandomUsed(v.ctx) {
+		summary.Seed = v.seed
+	}
+
+	return summary, nil
+}
+
+// Close releases the database connection
+func (v *Validator) Close() error {
+	return v.db.Close()
+}